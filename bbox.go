@@ -6,16 +6,17 @@ type BBox struct {
 	MinX, MinY, MaxX, MaxY float64
 }
 
-func (b BBox) area() float64 {
-	return (b.MaxX - b.MinX) * (b.MaxY - b.MinY)
+// bbox of an interface of length 1
+func interfaceBBox(p Interface) BBox {
+	x1, y1, x2, y2 := p.GetBBoxAt(0)
+	return BBox{
+		MinX: x1,
+		MaxX: x2,
+		MinY: y1,
+		MaxY: y2,
+	}
 }
 
-func (b1 BBox) equals (b2 BBox) bool {
-	return b1.MinX == b2.MinX &&
-		b1.MinY == b2.MinY &&
-		b1.MaxX == b2.MaxX &&
-		b1.MaxY == b2.MaxY
-}
 func (b1 BBox) extend(b2 BBox) BBox {
 	return BBox{
 		MinX: math.Min(b1.MinX, b2.MinX),
@@ -25,14 +26,6 @@ func (b1 BBox) extend(b2 BBox) BBox {
 	}
 }
 
-func (b1 BBox) intersectionArea(b2 BBox) float64 {
-	minX := math.Max(b1.MinX, b2.MinX)
-	maxX := math.Min(b1.MaxX, b2.MaxX)
-	minY := math.Max(b1.MinY, b2.MinY)
-	maxY := math.Min(b1.MaxY, b2.MaxY)
-	return math.Max(0, maxX-minX) * math.Min(0, maxY-minY)
-}
-
 func (b1 BBox) contains(b2 BBox) bool {
 	return b1.MinX <= b2.MinX &&
 		b2.MaxX <= b1.MaxX &&
@@ -47,7 +40,37 @@ func (b1 BBox) intersects(b2 BBox) bool {
 		b2.MaxY >= b1.MinY
 }
 
-func (b1 BBox) enlargedArea(b2 BBox) float64 {
-	return (math.Max(b2.MaxX, b1.MaxX) - math.Min(b2.MinX, b1.MinX)) *
-		(math.Max(b2.MaxY, b1.MaxY) - math.Min(b2.MinY, b1.MinY))
+// squared distance from the point to the closest point of the box. 0 if the point is inside
+func (b BBox) sqDistanceToPoint(x, y float64) float64 {
+	dx := axisDistance(x, b.MinX, b.MaxX)
+	dy := axisDistance(y, b.MinY, b.MaxY)
+	return dx*dx + dy*dy
+}
+
+func axisDistance(k, min, max float64) float64 {
+	if k < min {
+		return min - k
+	}
+	if k <= max {
+		return 0
+	}
+	return k - max
+}
+
+// flat layout of the bbox as stored by the index, see emptyBox
+func (b BBox) flat() [4]float64 {
+	return [4]float64{b.MinX, b.MinY, b.MaxX, b.MaxY}
+}
+
+func flatBBox(c []float64) BBox {
+	return BBox{MinX: c[0], MinY: c[1], MaxX: c[2], MaxY: c[3]}
+}
+
+// flat layout of the bboxes, one after the other
+func flatBBoxes(boxes []BBox) []float64 {
+	result := make([]float64, 0, 4*len(boxes))
+	for _, b := range boxes {
+		result = append(result, b.MinX, b.MinY, b.MaxX, b.MaxY)
+	}
+	return result
 }
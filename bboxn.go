@@ -0,0 +1,155 @@
+package go_rbush
+
+import "math"
+
+// Coordinate is the type of the coordinates of an index. Comparisons are done in the coordinate type,
+// so intersection is exact for integers. Volumes, margins and distances are computed as float64
+type Coordinate interface {
+	~int32 | ~float32 | ~float64
+}
+
+// BBoxOf is an N dimensional bbox. Min and Max hold one coordinate per dimension
+type BBoxOf[C Coordinate] struct {
+	Min, Max []C
+}
+
+// BBoxN is an N dimensional bbox with float64 coordinates
+type BBoxN = BBoxOf[float64]
+
+// bounds of the coordinate type, infinities for floating point types. Only integers truncate one/2 to 0
+func coordinateBounds[C Coordinate]() (lowest, highest C) {
+	var one C = 1
+	if one/2 != 0 {
+		return C(math.Inf(-1)), C(math.Inf(1))
+	}
+	return math.MinInt32, math.MaxInt32
+}
+
+func minCoordinate[C Coordinate](a, b C) C {
+	if b < a {
+		return b
+	}
+	return a
+}
+
+func maxCoordinate[C Coordinate](a, b C) C {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// flat layout of the bbox, see emptyBox. nil if it does not have dims dimensions
+func (b BBoxOf[C]) flat(dims int) []C {
+	if len(b.Min) != dims || len(b.Max) != dims {
+		return nil
+	}
+	return append(append(make([]C, 0, 2*dims), b.Min...), b.Max...)
+}
+
+// Nodes and items store their bboxes flat, the min coordinate of every dimension followed by the max ones,
+// so a 2D box has the layout of BBox: minX, minY, maxX, maxY. The number of dimensions is half the length
+
+// empty box, extending it with any other box results in the other box
+func emptyBox[C Coordinate](dims int) []C {
+	b := make([]C, 2*dims)
+	resetBox(b)
+	return b
+}
+
+func resetBox[C Coordinate](b []C) {
+	lowest, highest := coordinateBounds[C]()
+	dims := len(b) / 2
+	for d := 0; d < dims; d++ {
+		b[d] = highest
+		b[dims+d] = lowest
+	}
+}
+
+// extend b in place so that it contains o
+func boxExtend[C Coordinate](b, o []C) {
+	dims := len(b) / 2
+	for d := 0; d < dims; d++ {
+		b[d] = minCoordinate(b[d], o[d])
+		b[dims+d] = maxCoordinate(b[dims+d], o[dims+d])
+	}
+}
+
+func boxEquals[C Coordinate](b, o []C) bool {
+	for i := range b {
+		if b[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func boxIntersects[C Coordinate](b, o []C) bool {
+	dims := len(b) / 2
+	for d := 0; d < dims; d++ {
+		if !(o[d] <= b[dims+d] && o[dims+d] >= b[d]) {
+			return false
+		}
+	}
+	return true
+}
+
+func boxContains[C Coordinate](b, o []C) bool {
+	dims := len(b) / 2
+	for d := 0; d < dims; d++ {
+		if !(b[d] <= o[d] && o[dims+d] <= b[dims+d]) {
+			return false
+		}
+	}
+	return true
+}
+
+// area in 2 dimensions, volume in 3...
+func boxVolume[C Coordinate](b []C) float64 {
+	dims := len(b) / 2
+	v := 1.0
+	for d := 0; d < dims; d++ {
+		v *= float64(b[dims+d]) - float64(b[d])
+	}
+	return v
+}
+
+// sum of the extents along each dimension
+func boxMargin[C Coordinate](b []C) float64 {
+	dims := len(b) / 2
+	m := 0.0
+	for d := 0; d < dims; d++ {
+		m += float64(b[dims+d]) - float64(b[d])
+	}
+	return m
+}
+
+// volume of the union of b and o
+func boxEnlargedVolume[C Coordinate](b, o []C) float64 {
+	dims := len(b) / 2
+	v := 1.0
+	for d := 0; d < dims; d++ {
+		v *= float64(maxCoordinate(b[dims+d], o[dims+d])) - float64(minCoordinate(b[d], o[d]))
+	}
+	return v
+}
+
+func boxIntersectionVolume[C Coordinate](b, o []C) float64 {
+	dims := len(b) / 2
+	v := 1.0
+	for d := 0; d < dims; d++ {
+		v *= math.Max(0, float64(minCoordinate(b[dims+d], o[dims+d]))-float64(maxCoordinate(b[d], o[d])))
+	}
+	return v
+}
+
+// squared distance from the point to the closest point of the box. 0 if the point is inside
+func boxSqDistanceToPoint[C Coordinate](b, p []C) float64 {
+	dims := len(b) / 2
+	sqDistance := 0.0
+	for d := 0; d < dims; d++ {
+		k := axisDistance(float64(p[d]), float64(b[d]), float64(b[dims+d]))
+		sqDistance += k * k
+	}
+	return sqDistance
+}
@@ -1,11 +1,18 @@
 package go_rbush
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
+	"runtime"
 	"testing"
 )
+// Before storing items contiguously in leaves
 // BenchmarkRBush_Load1Million-4   	       5	1453557818 ns/op	180832276 B/op	 2291974 allocs/op
+// BenchmarkRBush_MemoryPerMillion 	       3	 979438827 ns/op	       135.3 MB/1M-items	150944186 B/op	 2292234 allocs/op
+// After
+// BenchmarkRBush_Load1Million     	       3	1479774512 ns/op	110342928 B/op	  292238 allocs/op
+// BenchmarkRBush_MemoryPerMillion 	       3	 861198353 ns/op	        74.90 MB/1M-items	89004794 B/op	  292234 allocs/op
 
 func BenchmarkRBush_Load1Million(b *testing.B) {
 	for i:= 0; i < b.N; i ++ {
@@ -16,6 +23,228 @@ func BenchmarkRBush_Load1Million(b *testing.B) {
 	}
 }
 
+func BenchmarkRBush_Load1MillionSequential(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var bigData = getData(1000000, 1)
+		tree := NewWithOptions(Options{MAX_ENTRIES: 16, MAX_WORKERS: 1}).
+			Load(bigData)
+		assert.Equal(b, tree.rootNode.height, 5)
+	}
+}
+
+// Memory retained by the index, without counting the data itself
+func BenchmarkRBush_MemoryPerMillion(b *testing.B) {
+	var bigData = getData(1000000, 1)
+	var tree *RBush
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		tree = nil
+		before := heapAlloc()
+		tree = NewWithOptions(Options{MAX_ENTRIES: 16}).
+			Load(bigData)
+		retained = heapAlloc() - before
+	}
+	runtime.KeepAlive(tree)
+	b.ReportMetric(float64(retained)/1e6, "MB/1M-items")
+}
+
+func BenchmarkRBush_OpenBinary1Million(b *testing.B) {
+	var bigData = getData(1000000, 1)
+	var buf bytes.Buffer
+	NewWithOptions(Options{MAX_ENTRIES: 16}).
+		Load(bigData).
+		WriteBinary(&buf, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree, err := OpenBinary(buf.Bytes(), bigData)
+		assert.NoError(b, err)
+		assert.Equal(b, tree.rootNode.height, 5)
+	}
+}
+
+func BenchmarkRBush_Insert100k(b *testing.B) {
+	var data = getData(100000, 1)
+	for i := 0; i < b.N; i++ {
+		tree := NewWithOptions(Options{MAX_ENTRIES: 16})
+		for j := range data {
+			tree.InsertElement(data[j : j+1])
+		}
+	}
+}
+
+// Before, breadth first search with a slice as queue
+// BenchmarkRBush_SearchSmallWindow   	  219987	      5362 ns/op	    3215 B/op	      32 allocs/op
+// BenchmarkRBush_SearchMediumWindow  	   13495	     96855 ns/op	  106614 B/op	     649 allocs/op
+// BenchmarkRBush_SearchHugeWindow    	     108	  12496671 ns/op	14847017 B/op	   52995 allocs/op
+// BenchmarkRBush_CollidesSmallWindow 	 1951923	       564.3 ns/op	      17 B/op	       0 allocs/op
+// After, depth first search with pooled stacks
+// BenchmarkRBush_SearchSmallWindow   	  330219	      3989 ns/op	    1774 B/op	      12 allocs/op
+// BenchmarkRBush_SearchMediumWindow  	   17912	     67568 ns/op	   51443 B/op	     306 allocs/op
+// BenchmarkRBush_SearchHugeWindow    	     222	   7200186 ns/op	 6279473 B/op	   25344 allocs/op
+// BenchmarkRBush_CollidesSmallWindow 	 2521935	       560.6 ns/op	       0 B/op	       0 allocs/op
+func BenchmarkRBush_SearchSmallWindow(b *testing.B) {
+	benchmarkSearch(b, 1)
+}
+
+func BenchmarkRBush_SearchMediumWindow(b *testing.B) {
+	benchmarkSearch(b, 10)
+}
+
+func BenchmarkRBush_SearchHugeWindow(b *testing.B) {
+	benchmarkSearch(b, 100)
+}
+
+func BenchmarkRBush_CollidesSmallWindow(b *testing.B) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 16}).Load(getData(100000, 1))
+	queries := getData(1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x1, y1, x2, y2 := queries.GetBBoxAt(i % len(queries))
+		tree.Collides(BBox{x1, y1, x2, y2})
+	}
+}
+
+func BenchmarkRBush_SearchWithinSmallRadius(b *testing.B) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 16}).Load(getData(100000, 1))
+	queries := getData(1000, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := queries[i%len(queries)]
+		tree.SearchWithin(q[0], q[1], 0.5)
+	}
+}
+
+// query windows of the given size over 100k items spread over 100x100
+func benchmarkSearch(b *testing.B, size float64) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 16}).Load(getData(100000, 1))
+	queries := getData(1000, size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x1, y1, x2, y2 := queries.GetBBoxAt(i % len(queries))
+		tree.Search(BBox{x1, y1, x2, y2})
+	}
+}
+
+// Packed index compared to Load, search returns indices instead of nodes
+// BenchmarkRBush_Load1Million            	       1	1077929372 ns/op	110333984 B/op	  291972 allocs/op
+// BenchmarkStaticIndex_Build1Million     	       3	 341747689 ns/op	46678354 B/op	      10 allocs/op
+// BenchmarkRBush_SearchSmallWindow       	  308029	      3731 ns/op	    1751 B/op	      12 allocs/op
+// BenchmarkStaticIndex_SearchSmallWindow 	  777850	      1614 ns/op	     205 B/op	       4 allocs/op
+func BenchmarkStaticIndex_Build1Million(b *testing.B) {
+	var bigData = getData(1000000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewStatic(bigData)
+	}
+}
+
+func BenchmarkStaticIndex_SearchSmallWindow(b *testing.B) {
+	index := NewStatic(getData(100000, 1))
+	queries := getData(1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x1, y1, x2, y2 := queries.GetBBoxAt(i % len(queries))
+		index.Search(BBox{x1, y1, x2, y2})
+	}
+}
+
+func BenchmarkRBushN_Load1Million3D(b *testing.B) {
+	var bigData = getHyperData(1000000, 3, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewNWithOptions(3, Options{MAX_ENTRIES: 16}).Load(bigData)
+	}
+}
+
+// Coordinate types of the generic index. Before, items kept a slice header of their own coordinates,
+// so smaller coordinates only saved 16 bytes per item in 2D
+// BenchmarkRBush_MemoryPerMillion      	       1	1525090117 ns/op	        73.34 MB/1M-items	110333632 B/op	  291969 allocs/op
+// BenchmarkRBushOf_Load1MillionFloat64 	       1	1860329696 ns/op	        98.43 MB/1M-items	149752928 B/op	  361614 allocs/op
+// BenchmarkRBushOf_Load1MillionFloat32 	       1	1509120857 ns/op	        81.31 MB/1M-items	132639552 B/op	  361614 allocs/op
+// BenchmarkRBushOf_Load1MillionInt32   	       1	1514489243 ns/op	        81.31 MB/1M-items	132639552 B/op	  361614 allocs/op
+// After, leaves store the coordinates of their items in a flat slice. RBush is the same tree with float64 coordinates
+// BenchmarkRBush_MemoryPerMillion      	       2	 691790759 ns/op	        67.71 MB/1M-items	89963424 B/op	  165776 allocs/op
+// BenchmarkRBushOf_Load1MillionFloat64 	       2	 721549145 ns/op	        67.71 MB/1M-items	73960352 B/op	  165776 allocs/op
+// BenchmarkRBushOf_Load1MillionFloat32 	       2	 803059628 ns/op	        50.60 MB/1M-items	56846976 B/op	  165776 allocs/op
+// BenchmarkRBushOf_Load1MillionInt32   	       2	 688614352 ns/op	        50.60 MB/1M-items	56846976 B/op	  165776 allocs/op
+func BenchmarkRBushOf_Load1MillionFloat64(b *testing.B) {
+	benchmarkLoadOf[float64](b)
+}
+
+func BenchmarkRBushOf_Load1MillionFloat32(b *testing.B) {
+	benchmarkLoadOf[float32](b)
+}
+
+func BenchmarkRBushOf_Load1MillionInt32(b *testing.B) {
+	benchmarkLoadOf[int32](b)
+}
+
+// 2D tile coordinates in a grid of 2^20 x 2^20, reports the memory kept by the index
+func benchmarkLoadOf[C Coordinate](b *testing.B) {
+	data := make(boxesOf[C], 1000000)
+	for i := range data {
+		x, y := rand.Int31n(1<<20), rand.Int31n(1<<20)
+		data[i] = []C{C(x), C(y), C(x + rand.Int31n(16)), C(y + rand.Int31n(16))}
+	}
+	b.ResetTimer()
+	var tree *RBushOf[C]
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		tree = nil
+		before := heapAlloc()
+		tree = NewOf[C](2, Options{MAX_ENTRIES: 16}).Load(data)
+		retained = heapAlloc() - before
+	}
+	runtime.KeepAlive(tree)
+	b.ReportMetric(float64(retained)/1e6, "MB/1M-items")
+}
+
+// Trees of BBox, so that items are not sliced when passed to fn. About 1M intersecting pairs
+// BenchmarkJoinTrees100k         	      14	  99039421 ns/op	      64 B/op	       2 allocs/op
+// BenchmarkTree_JoinBySearch100k 	       7	 179347086 ns/op	     187 B/op	       0 allocs/op
+func BenchmarkJoinTrees100k(b *testing.B) {
+	parcels := NewTreeWithOptions(identityBBox, Options{MAX_ENTRIES: 16}).Load(getBBoxes(100000, 1))
+	zones := NewTreeWithOptions(identityBBox, Options{MAX_ENTRIES: 16}).Load(getBBoxes(100000, 1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		JoinTrees(parcels, zones, func(a, b BBox) bool {
+			return true
+		})
+	}
+}
+
+func BenchmarkTree_JoinBySearch100k(b *testing.B) {
+	parcels := getBBoxes(100000, 1)
+	zones := NewTreeWithOptions(identityBBox, Options{MAX_ENTRIES: 16}).Load(getBBoxes(100000, 1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range parcels {
+			zones.SearchFunc(p, func(BBox) bool {
+				return true
+			})
+		}
+	}
+}
+
+func identityBBox(b BBox) BBox {
+	return b
+}
+
+func getBBoxes(N int, size float64) []BBox {
+	result := make([]BBox, N)
+	for i, d := range getData(N, size) {
+		result[i] = BBox{d[0], d[1], d[2], d[3]}
+	}
+	return result
+}
+
+func heapAlloc() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
 func randBox(size float64) [4]float64 {
 	x := rand.Float64() * (100 - size)
 	y := rand.Float64() * (100 - size)
@@ -0,0 +1,276 @@
+package go_rbush
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Binary layout, all numbers little endian:
+//
+//	header   magic "RBSH", version uint16, min entries uint16 (0 for the default), max entries uint32, height uint32, node count uint64, item count uint64
+//	nodes    in depth first order. minX, minY, maxX, maxY float64, number of entries uint32, reserved uint32
+//	         leaves are followed by their items: minX, minY, maxX, maxY float64, item index uint64
+//	trailer  crc32 (Castagnoli) of header and nodes, uint32
+const (
+	binaryMagic       = "RBSH"
+	BinaryVersion     = 1
+	binaryHeaderSize  = 32
+	binaryNodeSize    = 40
+	binaryItemSize    = 40
+	binaryTrailerSize = 4
+)
+
+// ErrInvalidBinary is wrapped by the errors returned when opening a malformed binary index
+var ErrInvalidBinary = errors.New("rbush: invalid binary index")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteBinary writes the index so it can be reopened with OpenBinary without loading it again.
+// Items are stored as indices into a collection that has to be given back on open. indexOf maps each item to its index.
+// If indexOf is nil the position of the item in the loaded collection is used, which is only valid
+// for an index built with a single Load
+func (r *RBush) WriteBinary(w io.Writer, indexOf func(item Interface) int) error {
+	nodeCount, itemCount := 0, 0
+	var collection Interface
+	for nodes := []*rbushNode{r.rootNode}; len(nodes) != 0; {
+		n := nodes[len(nodes)-1]
+		nodes = append(nodes[:len(nodes)-1], n.children...)
+		nodeCount++
+		itemCount += len(n.values)
+		if indexOf != nil {
+			continue
+		}
+		for _, it := range n.values {
+			if collection == nil {
+				collection = it.points
+			}
+			if !sameCollection(it.points, collection) {
+				return errors.New("rbush: items do not come from a single loaded collection, an indexOf function is required")
+			}
+		}
+	}
+
+	crc := crc32.New(crcTable)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	header := make([]byte, binaryHeaderSize)
+	copy(header, binaryMagic)
+	binary.LittleEndian.PutUint16(header[4:], BinaryVersion)
+	binary.LittleEndian.PutUint16(header[6:], uint16(r.options.MIN_ENTRIES))
+	binary.LittleEndian.PutUint32(header[8:], uint32(r.options.MAX_ENTRIES))
+	binary.LittleEndian.PutUint32(header[12:], uint32(r.rootNode.height))
+	binary.LittleEndian.PutUint64(header[16:], uint64(nodeCount))
+	binary.LittleEndian.PutUint64(header[24:], uint64(itemCount))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]byte, binaryNodeSize)
+	var writeNode func(n *rbushNode) error
+	writeNode = func(n *rbushNode) error {
+		putBinaryBBox(record, n.bbox)
+		binary.LittleEndian.PutUint32(record[32:], uint32(n.numEntries()))
+		binary.LittleEndian.PutUint32(record[36:], 0)
+		if _, err := bw.Write(record); err != nil {
+			return err
+		}
+		for i, it := range n.values {
+			index := it.index
+			if indexOf != nil {
+				index = indexOf(it.value())
+			}
+			putBinaryBBox(record, n.entryBox(i))
+			binary.LittleEndian.PutUint64(record[32:], uint64(index))
+			if _, err := bw.Write(record); err != nil {
+				return err
+			}
+		}
+		for _, c := range n.children {
+			if err := writeNode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writeNode(r.rootNode); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	trailer := make([]byte, binaryTrailerSize)
+	binary.LittleEndian.PutUint32(trailer, crc.Sum32())
+	_, err := w.Write(trailer)
+	return err
+}
+
+// OpenBinary reopens an index written by WriteBinary. It is not zero-copy: data is decoded in a single linear pass
+// into a few allocations sized from the header, and nothing is sorted. data is not retained, so a memory mapped file
+// can be unmapped afterwards. Items reference points by the indices that were written, points is only used to check
+// that they are in range and cannot be nil
+func OpenBinary(data []byte, points Interface) (*RBush, error) {
+	if points == nil {
+		return nil, errors.New("rbush: OpenBinary requires the points referenced by the index")
+	}
+	if len(data) < binaryHeaderSize+binaryTrailerSize || string(data[0:4]) != binaryMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidBinary)
+	}
+	if version := binary.LittleEndian.Uint16(data[4:]); version != BinaryVersion {
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrInvalidBinary, version)
+	}
+	body := data[:len(data)-binaryTrailerSize]
+	if crc32.Checksum(body, crcTable) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidBinary)
+	}
+	minEntries := int(binary.LittleEndian.Uint16(data[6:]))
+	maxEntries := int(binary.LittleEndian.Uint32(data[8:]))
+	height := int(binary.LittleEndian.Uint32(data[12:]))
+	nodeCount := binary.LittleEndian.Uint64(data[16:])
+	itemCount := binary.LittleEndian.Uint64(data[24:])
+	options := Options{MAX_ENTRIES: maxEntries, MIN_ENTRIES: minEntries}
+	if err := options.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: invalid header, %v", ErrInvalidBinary, err)
+	}
+	if height < 1 || nodeCount > uint64(len(body)) || itemCount > uint64(len(body)) {
+		return nil, fmt.Errorf("%w: invalid header", ErrInvalidBinary)
+	}
+	if uint64(len(body)) != binaryHeaderSize+nodeCount*binaryNodeSize+itemCount*binaryItemSize {
+		return nil, fmt.Errorf("%w: size does not match header", ErrInvalidBinary)
+	}
+
+	r := NewWithOptions(options)
+	d := binaryDecoder{
+		data:        body,
+		offset:      binaryHeaderSize,
+		maxEntries:  maxEntries,
+		points:      points,
+		cow:         r.cow,
+		nodes:       make([]rbushNode, nodeCount),
+		children:    make([]*rbushNode, max(int(nodeCount)-1, 0)),
+		values:      make([]item, itemCount),
+		coordinates: make([]float64, 4*itemCount),
+		bboxes:      make([]float64, 4*nodeCount),
+	}
+	root, err := d.readNode(height)
+	if err != nil {
+		return nil, err
+	}
+	if d.offset != len(body) {
+		return nil, fmt.Errorf("%w: unexpected data after nodes", ErrInvalidBinary)
+	}
+	if root.numEntries() != 0 {
+		r.rootNode = root
+	}
+	return r, nil
+}
+
+type binaryDecoder struct {
+	data       []byte
+	offset     int
+	maxEntries int
+	points     Interface
+	cow        *copyOnWrite
+	// allocated from the header counts and handed out as they are read.
+	// Slices are capped so that appending to a node does not overwrite the next one
+	nodes       []rbushNode
+	children    []*rbushNode
+	values      []item
+	coordinates []float64
+	bboxes      []float64
+}
+
+func (d *binaryDecoder) readNode(height int) (*rbushNode, error) {
+	if len(d.nodes) == 0 || d.offset+binaryNodeSize > len(d.data) {
+		return nil, fmt.Errorf("%w: truncated node", ErrInvalidBinary)
+	}
+	record := d.data[d.offset : d.offset+binaryNodeSize]
+	d.offset += binaryNodeSize
+	n := &d.nodes[0]
+	d.nodes = d.nodes[1:]
+	*n = rbushNode{
+		bbox:   d.bboxes[:4:4],
+		height: height,
+		isLeaf: height == 1,
+		cow:    d.cow,
+	}
+	d.bboxes = d.bboxes[4:]
+	readBinaryBBox(n.bbox, record)
+	count := int(binary.LittleEndian.Uint32(record[32:]))
+	if count > d.maxEntries {
+		return nil, fmt.Errorf("%w: node with %v entries, more than the maximum %v", ErrInvalidBinary, count, d.maxEntries)
+	}
+	if n.isLeaf {
+		if count > len(d.values) || count*binaryItemSize > len(d.data)-d.offset {
+			return nil, fmt.Errorf("%w: truncated leaf", ErrInvalidBinary)
+		}
+		n.values = d.values[:count:count]
+		d.values = d.values[count:]
+		n.coordinates = d.coordinates[: 4*count : 4*count]
+		d.coordinates = d.coordinates[4*count:]
+		for i := range n.values {
+			record = d.data[d.offset : d.offset+binaryItemSize]
+			d.offset += binaryItemSize
+			index := binary.LittleEndian.Uint64(record[32:])
+			if index >= uint64(d.points.Len()) {
+				return nil, fmt.Errorf("%w: item index %v out of range", ErrInvalidBinary, index)
+			}
+			readBinaryBBox(n.entryBox(i), record)
+			n.values[i] = item{points: d.points, index: int(index)}
+		}
+		return n, nil
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("%w: node without children", ErrInvalidBinary)
+	}
+	if count > len(d.children) || count*binaryNodeSize > len(d.data)-d.offset {
+		return nil, fmt.Errorf("%w: truncated node", ErrInvalidBinary)
+	}
+	n.children = d.children[:count:count]
+	d.children = d.children[count:]
+	for i := range n.children {
+		child, err := d.readNode(height - 1)
+		if err != nil {
+			return nil, err
+		}
+		// only the root can be empty
+		if child.numEntries() == 0 {
+			return nil, fmt.Errorf("%w: node with an empty child", ErrInvalidBinary)
+		}
+		n.children[i] = child
+	}
+	return n, nil
+}
+
+// tells whether both collections are the same. Slices are not comparable, they are the same if they share
+// their first element and length
+func sameCollection(a, b Interface) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	if va.Kind() == reflect.Slice {
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	}
+	if va.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// bboxes are written in their flat layout, minX, minY, maxX, maxY
+func putBinaryBBox(b []byte, bbox []float64) {
+	for i, c := range bbox {
+		binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(c))
+	}
+}
+
+func readBinaryBBox(bbox []float64, b []byte) {
+	for i := range bbox {
+		bbox[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+}
@@ -0,0 +1,168 @@
+package go_rbush
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+func TestRBush_BinaryRoundTrip(t *testing.T) {
+	data := getData(5000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(data)
+	var buf bytes.Buffer
+	assertNoError(t, tree.WriteBinary(&buf, nil))
+
+	// items are stored by their position in the loaded collection, which is stored along with the index
+	stored := append(bboxes{}, data...)
+	reopened, err := OpenBinary(buf.Bytes(), stored)
+	assertNoError(t, err)
+	assertNoError(t, reopened.Check())
+	assertEqual(t, reopened.options.MAX_ENTRIES, 9, "")
+	assertEqual(t, reopened.rootNode.height, tree.rootNode.height, "")
+	assertEqual(t, reopened.ToBBox(), tree.ToBBox(), "")
+	for _, q := range getData(50, 10) {
+		b := BBox{q[0], q[1], q[2], q[3]}
+		expected := tree.SearchItems(b)
+		result := reopened.SearchItems(b)
+		assertEqual(t, len(result), len(expected), "")
+		for i := range result {
+			assertEqual(t, result[i].(bboxes)[0], expected[i].(bboxes)[0], "")
+		}
+	}
+	// reopened index can be modified
+	assertNoError(t, reopened.InsertElement(bboxes{{150, 150, 150, 150}}))
+	assertEqual(t, removeElement(t, reopened, bboxToRemove(stored[0])), true, "")
+	assertNoError(t, reopened.Check())
+}
+
+func TestRBush_BinaryKeepsMinEntries(t *testing.T) {
+	data := getData(500, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9, MIN_ENTRIES: 2}).Load(data)
+	var buf bytes.Buffer
+	assertNoError(t, tree.WriteBinary(&buf, nil))
+	reopened, err := OpenBinary(buf.Bytes(), data)
+	assertNoError(t, err)
+	assertEqual(t, reopened.options, Options{MAX_ENTRIES: 9, MIN_ENTRIES: 2}, "")
+}
+
+func TestRBush_BinaryWithIndexOf(t *testing.T) {
+	data := getDataExample()
+	features := make(featureList, len(data))
+	for i, d := range data {
+		features[i] = feature{id: i, bbox: d}
+	}
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(features[0:20])
+	for i := 20; i < len(features); i++ {
+		assertNoError(t, tree.InsertElement(features[i:i+1]))
+	}
+	var buf bytes.Buffer
+	err := tree.WriteBinary(&buf, nil)
+	assertEqual(t, err != nil, true, "Items inserted one by one cannot be written without indexOf")
+
+	buf.Reset()
+	assertNoError(t, tree.WriteBinary(&buf, func(item Interface) int {
+		return item.(featureList)[0].id
+	}))
+	original := make(featureList, len(data))
+	for i, d := range data {
+		original[i] = feature{id: i, bbox: d}
+	}
+	reopened, err := OpenBinary(buf.Bytes(), original)
+	assertNoError(t, err)
+	assertNoError(t, reopened.Check())
+	items := reopened.SearchItems(BBox{40, 20, 80, 70})
+	assertEqual(t, len(items), 12, "")
+	for _, item := range items {
+		f := item.(featureList)[0]
+		assertEqual(t, f.bbox, data[f.id], "")
+	}
+}
+
+func TestRBush_BinaryEmptyTree(t *testing.T) {
+	var buf bytes.Buffer
+	assertNoError(t, New().WriteBinary(&buf, nil))
+	reopened, err := OpenBinary(buf.Bytes(), bboxes{})
+	assertNoError(t, err)
+	assertNoError(t, reopened.Check())
+	assertEqual(t, len(reopened.All()), 0, "")
+}
+
+func TestRBush_BinaryInvalid(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	var buf bytes.Buffer
+	assertNoError(t, tree.WriteBinary(&buf, nil))
+	encoded := buf.Bytes()
+
+	corrupted := append([]byte{}, encoded...)
+	corrupted[100]++
+	_, err := OpenBinary(corrupted, data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Checksum should not match")
+
+	otherVersion := append([]byte{}, encoded...)
+	otherVersion[4] = BinaryVersion + 1
+	_, err = OpenBinary(otherVersion, data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Version should not be supported")
+
+	_, err = OpenBinary(encoded[:len(encoded)-50], data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Truncated data should be rejected")
+
+	_, err = OpenBinary([]byte("not an index"), data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Missing header should be rejected")
+
+	_, err = OpenBinary(encoded, data[0:10])
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Indices out of range should be rejected")
+}
+
+func TestRBush_BinaryRequiresIndexOfForSeveralLoads(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getData(100, 1))
+	tree.Load(getData(100, 1))
+	var buf bytes.Buffer
+	err := tree.WriteBinary(&buf, nil)
+	assertEqual(t, err != nil, true, "Items of collections of the same length cannot be written without indexOf")
+}
+
+func TestRBush_BinaryRejectsTooManyEntries(t *testing.T) {
+	data := getData(100, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	var buf bytes.Buffer
+	assertNoError(t, tree.WriteBinary(&buf, nil))
+	encoded := buf.Bytes()
+
+	// root entries beyond max entries, with a valid checksum
+	body := encoded[:len(encoded)-binaryTrailerSize]
+	binary.LittleEndian.PutUint32(body[binaryHeaderSize+32:], 1<<30)
+	binary.LittleEndian.PutUint32(encoded[len(body):], crc32.Checksum(body, crcTable))
+	_, err := OpenBinary(encoded, data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Nodes with more than max entries should be rejected")
+}
+
+func TestRBush_BinaryRejectsEmptyLeaves(t *testing.T) {
+	data := getData(20, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(data)
+	assertEqual(t, tree.rootNode.height, 2, "")
+	leaf := tree.rootNode.children[0]
+	leaf.values, leaf.coordinates = nil, nil
+	var buf bytes.Buffer
+	assertNoError(t, tree.WriteBinary(&buf, nil))
+	_, err := OpenBinary(buf.Bytes(), data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Empty leaves other than the root should be rejected")
+}
+
+func TestRBush_BinaryValidatesOptions(t *testing.T) {
+	data := getData(2, 1)
+	var buf bytes.Buffer
+	assertNoError(t, NewWithOptions(Options{MAX_ENTRIES: 3}).Load(data).WriteBinary(&buf, nil))
+	_, err := OpenBinary(buf.Bytes(), data)
+	assertEqual(t, errors.Is(err, ErrInvalidBinary), true, "Max entries should be validated as in Options.Validate")
+}
+
+func TestRBush_BinaryRequiresPoints(t *testing.T) {
+	data := getData(100, 1)
+	var buf bytes.Buffer
+	assertNoError(t, New().Load(data).WriteBinary(&buf, nil))
+	_, err := OpenBinary(buf.Bytes(), nil)
+	assertEqual(t, err != nil, true, "")
+}
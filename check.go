@@ -0,0 +1,76 @@
+package go_rbush
+
+import "fmt"
+
+// Check walks the whole index verifying its invariants: heights, leaves, children and bboxes.
+// It is meant to be used in tests to detect corrupted trees, it returns a *TreeError describing the first problem found
+func (r *RBush) Check() error {
+	return r.check(func(it item) bool {
+		return it.points != nil && it.index >= 0 && it.index < it.points.Len()
+	})
+}
+
+// validValue tells whether an item is well formed, it can be nil
+func (t *rtree[C, E]) check(validValue func(E) bool) error {
+	root := t.rootNode
+	if root == nil {
+		return &TreeError{Op: "check", Msg: "missing root node"}
+	}
+	if root.numEntries() == 0 {
+		if !root.isLeaf || root.height != 1 {
+			return &TreeError{Op: "check", Msg: "empty root should be a leaf of height 1"}
+		}
+		return nil
+	}
+	nodesToCheck := []*rnode[C, E]{root}
+	var node *rnode[C, E]
+	for len(nodesToCheck) != 0 {
+		node, nodesToCheck = nodesToCheck[len(nodesToCheck)-1], nodesToCheck[:len(nodesToCheck)-1]
+		if len(node.bbox) != 2*t.dims {
+			return checkError(node, "bbox with wrong dimensions")
+		}
+		if node.isLeaf != (node.height == 1) {
+			return checkError(node, "only nodes of height 1 can be leaves")
+		}
+		if node.numEntries() == 0 {
+			return checkError(node, "node without children")
+		}
+		if node.numEntries() > t.options.MAX_ENTRIES {
+			return checkError(node, fmt.Sprintf("node has %v children, more than max entries", node.numEntries()))
+		}
+		if node.isLeaf && len(node.coordinates) != len(node.values)*len(node.bbox) {
+			return checkError(node, "leaf bboxes do not match its items")
+		}
+		if !boxEquals(node.bbox, node.partialBBox(make([]C, len(node.bbox)), 0, node.numEntries())) {
+			return checkError(node, "bbox does not match children")
+		}
+		if node.isLeaf {
+			if len(node.children) != 0 {
+				return checkError(node, "leaf with children nodes")
+			}
+			for _, v := range node.values {
+				if validValue != nil && !validValue(v) {
+					return checkError(node, "item does not reference a point")
+				}
+			}
+			continue
+		}
+		if len(node.values) != 0 {
+			return checkError(node, "non leaf node with items")
+		}
+		for _, c := range node.children {
+			if c == nil {
+				return checkError(node, "nil child")
+			}
+			if c.height != node.height-1 {
+				return checkError(node, fmt.Sprintf("child of height %v", c.height))
+			}
+			nodesToCheck = append(nodesToCheck, c)
+		}
+	}
+	return nil
+}
+
+func checkError[C Coordinate, E any](n *rnode[C, E], msg string) error {
+	return &TreeError{Op: "check", Msg: fmt.Sprintf("node of height %v with bbox %v: %v", n.height, n.bbox, msg)}
+}
@@ -0,0 +1,72 @@
+package go_rbush
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRBush_CheckValidTrees(t *testing.T) {
+	assertNoError(t, New().Check())
+	assertNoError(t, NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample()).Check())
+	assertNoError(t, NewWithOptions(Options{MAX_ENTRIES: 16}).Load(getData(10000, 1)).Check())
+	assertNoError(t, NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample()).Load(getDataExample()).Check())
+	assertNoError(t, NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample()).Load(getSomeDataBBoxes(9)).Check())
+
+	data := getData(1000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4})
+	for i := range data {
+		assertNoError(t, tree.InsertElement(data[i:i+1]))
+	}
+	assertNoError(t, tree.Check())
+	for i := 0; i < 700; i++ {
+		_, err := tree.RemoveElement(bboxToRemove(data[i]))
+		assertNoError(t, err)
+	}
+	assertNoError(t, tree.Check())
+}
+
+func TestRBush_CheckDetectsCorruption(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	copy(tree.rootNode.children[0].bbox, []float64{0, 0, 1, 1})
+	assertCorrupted(t, tree.Check())
+
+	tree = NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	tree.rootNode.children[0].isLeaf = !tree.rootNode.children[0].isLeaf
+	assertCorrupted(t, tree.Check())
+
+	tree = NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	tree.rootNode.children[0].height++
+	assertCorrupted(t, tree.Check())
+}
+
+func TestRBush_InsertElementReturnsErrorOnCorruptedTree(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	// pretend the tree is taller than it is, items would be inserted below leaves
+	tree.rootNode.height++
+	assertCorrupted(t, tree.InsertElement(data[0:1]))
+}
+
+func TestRBush_LoadPanicsWithTreeError(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	tree.rootNode.height++
+	defer func() {
+		err, ok := recover().(error)
+		assertEqual(t, ok, true, "Load should panic with an error")
+		assertCorrupted(t, err)
+	}()
+	tree.Load(getSomeDataBBoxes(1))
+}
+
+func assertNoError(t *testing.T, err error) {
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func assertCorrupted(t *testing.T, err error) {
+	var treeError *TreeError
+	if !errors.As(err, &treeError) || !errors.Is(err, ErrCorruptedTree) {
+		t.Errorf("Expected a tree error, got %v", err)
+	}
+}
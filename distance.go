@@ -0,0 +1,96 @@
+package go_rbush
+
+import "math"
+
+// SearchWithin returns the items whose bbox is at distance at most distance of the point (x, y).
+// Each item is an Interface of length 1
+func (r *RBush) SearchWithin(x, y, distance float64) []Interface {
+	return r.SearchWithinBBox(BBox{x, y, x, y}, distance)
+}
+
+// SearchWithinSq is like SearchWithin but takes the squared distance, avoiding the square root on the caller side
+func (r *RBush) SearchWithinSq(x, y, sqDistance float64) []Interface {
+	return r.SearchWithinBBoxSq(BBox{x, y, x, y}, sqDistance)
+}
+
+// SearchWithinBBox returns the items whose bbox is at distance at most distance of the bbox, that is,
+// the items intersecting the bbox buffered by distance with rounded corners
+func (r *RBush) SearchWithinBBox(b BBox, distance float64) []Interface {
+	if distance < 0 {
+		return []Interface{}
+	}
+	return r.SearchWithinBBoxSq(b, distance*distance)
+}
+
+// SearchWithinBBoxSq is like SearchWithinBBox but takes the squared distance
+func (r *RBush) SearchWithinBBoxSq(b BBox, sqDistance float64) []Interface {
+	buf := getItemBuffer()
+	defer putItemBuffer(buf)
+	q := b.flat()
+	r.searchWithin(q[:], sqDistance, func(leaf *rbushNode, i int) bool {
+		buf.refs = append(buf.refs, itemRef{leaf: leaf, index: i})
+		return true
+	})
+	return r.interfaces(buf.refs)
+}
+
+// visit the items at squared distance at most sqDistance of the bbox, returns false if fn stopped the search
+func (t *rtree[C, E]) searchWithin(b []C, sqDistance float64, fn func(leaf *rnode[C, E], i int) bool) bool {
+	root := t.rootNode
+	if root.numEntries() == 0 || boxSqDistance(b, root.bbox) > sqDistance {
+		return true
+	}
+	return root.walkWithin(b, sqDistance, boxFarthestSqDistance(b, root.bbox) <= sqDistance, fn)
+}
+
+// as walk, subtrees farther than sqDistance are pruned and subtrees completely within it are not checked
+func (n *rnode[C, E]) walkWithin(b []C, sqDistance float64, contained bool, fn func(leaf *rnode[C, E], i int) bool) bool {
+	stack := getWalkStack[C, E]()
+	defer putWalkStack(stack)
+	stack.entries = append(stack.entries, walkEntry[C, E]{n, contained})
+	for len(stack.entries) != 0 {
+		e := stack.entries[len(stack.entries)-1]
+		stack.entries = stack.entries[:len(stack.entries)-1]
+		node := e.node
+		if node.isLeaf {
+			for i := range node.values {
+				if (e.contained || boxSqDistance(b, node.entryBox(i)) <= sqDistance) && !fn(node, i) {
+					return false
+				}
+			}
+			continue
+		}
+		for i := len(node.children) - 1; i >= 0; i-- {
+			c := node.children[i]
+			if e.contained {
+				stack.entries = append(stack.entries, walkEntry[C, E]{c, true})
+			} else if boxSqDistance(b, c.bbox) <= sqDistance {
+				stack.entries = append(stack.entries, walkEntry[C, E]{c, boxFarthestSqDistance(b, c.bbox) <= sqDistance})
+			}
+		}
+	}
+	return true
+}
+
+// squared distance between the closest points of both boxes. 0 if they intersect
+func boxSqDistance[C Coordinate](b1, b2 []C) float64 {
+	dims := len(b1) / 2
+	sqDistance := 0.0
+	for d := 0; d < dims; d++ {
+		k := math.Max(0, math.Max(float64(b2[d])-float64(b1[dims+d]), float64(b1[d])-float64(b2[dims+d])))
+		sqDistance += k * k
+	}
+	return sqDistance
+}
+
+// largest squared distance from a point of b2 to b1, it is reached at one of the corners of b2
+func boxFarthestSqDistance[C Coordinate](b1, b2 []C) float64 {
+	dims := len(b1) / 2
+	sqDistance := 0.0
+	for d := 0; d < dims; d++ {
+		lo, hi := float64(b1[d]), float64(b1[dims+d])
+		k := math.Max(axisDistance(float64(b2[d]), lo, hi), axisDistance(float64(b2[dims+d]), lo, hi))
+		sqDistance += k * k
+	}
+	return sqDistance
+}
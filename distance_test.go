@@ -0,0 +1,98 @@
+package go_rbush
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// bboxes of the items, sorted, so results of different queries can be compared
+func sortedBBoxes(items []Interface) bboxes {
+	result := make(bboxes, len(items))
+	for i, it := range items {
+		b := interfaceBBox(it)
+		result[i] = [4]float64{b.MinX, b.MinY, b.MaxX, b.MaxY}
+	}
+	sort.Sort(result)
+	return result
+}
+
+func assertSameBBoxes(t *testing.T, result, expected bboxes) {
+	assertEqual(t, len(result), len(expected), "")
+	for i := range result {
+		assertEqual(t, result[i], expected[i], "")
+	}
+}
+
+func bruteForceWithin(data bboxes, b BBox, sqDistance float64) bboxes {
+	result := bboxes{}
+	for _, d := range data {
+		item := BBox{d[0], d[1], d[2], d[3]}
+		// distance between closest points along each axis
+		dx := math.Max(0, math.Max(item.MinX-b.MaxX, b.MinX-item.MaxX))
+		dy := math.Max(0, math.Max(item.MinY-b.MaxY, b.MinY-item.MaxY))
+		if dx*dx+dy*dy <= sqDistance {
+			result = append(result, d)
+		}
+	}
+	sort.Sort(result)
+	return result
+}
+
+func TestRBush_SearchWithinMatchesBruteForce(t *testing.T) {
+	data := getData(10000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data...))
+	for _, distance := range []float64{0, 0.5, 3, 20, 200} {
+		for i := 0; i < 20; i++ {
+			x, y := rand.Float64()*120-10, rand.Float64()*120-10
+			expected := bruteForceWithin(data, BBox{x, y, x, y}, distance*distance)
+			assertSameBBoxes(t, sortedBBoxes(tree.SearchWithin(x, y, distance)), expected)
+			assertSameBBoxes(t, sortedBBoxes(tree.SearchWithinSq(x, y, distance*distance)), expected)
+
+			q := randBox(10)
+			b := BBox{q[0], q[1], q[2], q[3]}
+			expected = bruteForceWithin(data, b, distance*distance)
+			assertSameBBoxes(t, sortedBBoxes(tree.SearchWithinBBox(b, distance)), expected)
+			assertSameBBoxes(t, sortedBBoxes(tree.SearchWithinBBoxSq(b, distance*distance)), expected)
+		}
+	}
+}
+
+func TestRBush_SearchWithin(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	// within distance 0 of a bbox is the same as intersecting it
+	for _, q := range getData(20, 30) {
+		b := BBox{q[0], q[1], q[2], q[3]}
+		assertSameBBoxes(t, sortedBBoxes(tree.SearchWithinBBox(b, 0)), sortedBBoxes(tree.SearchItems(b)))
+	}
+	// corners are rounded, (0, 0) is at distance 5 of (3, -4)
+	assertEqual(t, len(tree.SearchWithin(3, -4, 5)), 1, "")
+	assertEqual(t, len(tree.SearchWithin(3, -4, 4.99)), 0, "")
+	assertEqual(t, len(tree.SearchWithinSq(3, -4, 25)), 1, "")
+	assertEqual(t, len(tree.SearchWithin(50, 50, 1000)), len(getDataExample()), "")
+	assertEqual(t, len(tree.SearchWithin(50, 50, -1)), 0, "")
+	assertEqual(t, len(New().SearchWithin(50, 50, 1000)), 0, "")
+}
+
+func TestTree_SearchWithin(t *testing.T) {
+	vehicles := getVehicles()
+	tree := NewTreeWithOptions(vehicleBBox, Options{MAX_ENTRIES: 4}).Load(vehicles)
+	for _, distance := range []float64{0, 5, 25} {
+		result := tree.SearchWithin(50, 50, distance)
+		expected := 0
+		for _, v := range vehicles {
+			if math.Hypot(v.x-50, v.y-50) <= distance {
+				expected++
+			}
+		}
+		assertEqual(t, len(result), expected, "")
+		for _, v := range result {
+			assertEqual(t, math.Hypot(v.x-50, v.y-50) <= distance, true, "")
+		}
+		assertEqual(t, len(tree.SearchWithinSq(50, 50, distance*distance)), expected, "")
+	}
+	assertEqual(t, len(tree.SearchWithinBBox(BBox{40, 20, 80, 70}, 0)), 12, "")
+	assertEqual(t, len(tree.SearchWithinBBoxSq(BBox{40, 20, 80, 70}, 0)), 12, "")
+	assertEqual(t, len(tree.SearchWithinBBox(BBox{40, 20, 80, 70}, -1)), 0, "")
+}
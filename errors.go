@@ -0,0 +1,38 @@
+package go_rbush
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCorruptedTree is wrapped by every TreeError, it signals that the index is in an inconsistent state
+var ErrCorruptedTree = errors.New("rbush: corrupted tree")
+
+// TreeError describes an internal inconsistency found while operating on the index.
+// Methods that return the index for chaining (Load, LoadSortedArray, Remove) panic with a *TreeError instead
+type TreeError struct {
+	Op  string // operation that found the inconsistency
+	Msg string
+}
+
+func (e *TreeError) Error() string {
+	return "rbush: " + e.Op + ": " + e.Msg
+}
+
+func (e *TreeError) Unwrap() error {
+	return ErrCorruptedTree
+}
+
+// LineError is returned by the loaders when a line of the input cannot be read. Lines start at 1
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("rbush: line %v: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,215 @@
+package go_rbush
+
+import "context"
+
+// Tree is a type safe index of items of type T. The bbox of each item is retrieved with the accessor given on creation.
+// It shares the generic rtree with RBush, leaves hold the T values directly next to their bboxes
+type Tree[T any] struct {
+	toBBox func(T) BBox
+	rtree[float64, T]
+}
+
+// NewTree creates an empty index of items of type T with default options
+func NewTree[T any](toBBox func(T) BBox) *Tree[T] {
+	return NewTreeWithOptions(toBBox, Options{MAX_ENTRIES: 9})
+}
+
+func NewTreeWithOptions[T any](toBBox func(T) BBox, options Options) *Tree[T] {
+	return &Tree[T]{
+		toBBox: toBBox,
+		rtree:  newRTree[float64, T](2, options),
+	}
+}
+
+// Load bulk inserts the items. The slice is copied, so the caller can keep using it
+func (t *Tree[T]) Load(items []T) *Tree[T] {
+	if err := t.LoadContext(context.Background(), items); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadContext is like Load but can be cancelled, see RBush.LoadContext
+func (t *Tree[T]) LoadContext(ctx context.Context, items []T) error {
+	coordinates := make([]float64, 0, 4*len(items))
+	for _, it := range items {
+		b := t.toBBox(it)
+		coordinates = append(coordinates, b.MinX, b.MinY, b.MaxX, b.MaxY)
+	}
+	return t.load(ctx, coordinates, append([]T(nil), items...), false)
+}
+
+// Insert adds a single item to the index
+func (t *Tree[T]) Insert(item T) error {
+	b := t.toBBox(item).flat()
+	return t.insertItem(b[:], item)
+}
+
+// Remove removes the first item with the same bbox for which equals returns true. Reports whether it was found
+func (t *Tree[T]) Remove(item T, equals func(a, b T) bool) (bool, error) {
+	b := t.toBBox(item).flat()
+	return t.remove(b[:], func(v T) bool {
+		return equals(item, v)
+	})
+}
+
+// Update replaces old by item, see RBush.Update
+func (t *Tree[T]) Update(old, item T, equals func(a, b T) bool) (bool, error) {
+	b, moved := t.toBBox(old).flat(), t.toBBox(item).flat()
+	return t.update(b[:], func(v T) bool {
+		return equals(old, v)
+	}, moved[:], item)
+}
+
+// Search returns all items intersecting the bbox
+func (t *Tree[T]) Search(b BBox) []T {
+	buf := getItemBuffer()
+	defer putItemBuffer(buf)
+	q := b.flat()
+	t.collect(q[:], buf)
+	return t.values(buf.refs)
+}
+
+// SearchFunc calls fn for every item intersecting the bbox until fn returns false. fn must not modify the tree
+func (t *Tree[T]) SearchFunc(b BBox, fn func(T) bool) {
+	q := b.flat()
+	t.search(q[:], func(leaf *rnode[float64, T], i int) bool {
+		return fn(leaf.values[i])
+	})
+}
+
+func (t *Tree[T]) Collides(b BBox) bool {
+	q := b.flat()
+	return t.collides(q[:])
+}
+
+// SearchWithin returns the items whose bbox is at distance at most distance of the point, see RBush.SearchWithin
+func (t *Tree[T]) SearchWithin(x, y, distance float64) []T {
+	return t.SearchWithinBBox(BBox{x, y, x, y}, distance)
+}
+
+// SearchWithinSq is like SearchWithin but takes the squared distance, see RBush.SearchWithinSq
+func (t *Tree[T]) SearchWithinSq(x, y, sqDistance float64) []T {
+	return t.SearchWithinBBoxSq(BBox{x, y, x, y}, sqDistance)
+}
+
+// SearchWithinBBox returns the items whose bbox is at distance at most distance of the bbox, see RBush.SearchWithinBBox
+func (t *Tree[T]) SearchWithinBBox(b BBox, distance float64) []T {
+	if distance < 0 {
+		return make([]T, 0)
+	}
+	return t.SearchWithinBBoxSq(b, distance*distance)
+}
+
+// SearchWithinBBoxSq is like SearchWithinBBox but takes the squared distance
+func (t *Tree[T]) SearchWithinBBoxSq(b BBox, sqDistance float64) []T {
+	result := make([]T, 0)
+	q := b.flat()
+	t.searchWithin(q[:], sqDistance, func(leaf *rnode[float64, T], i int) bool {
+		result = append(result, leaf.values[i])
+		return true
+	})
+	return result
+}
+
+// CollidesAll tells for each bbox whether some item intersects it, see RBush.CollidesAll
+func (t *Tree[T]) CollidesAll(boxes []BBox) []bool {
+	return t.collidesAll(flatBBoxes(boxes))
+}
+
+// Knn returns the k items closest to the point, see RBush.Knn
+func (t *Tree[T]) Knn(x, y float64, k int, maxDistance float64, filter func(item T) bool) []T {
+	result := make([]T, 0)
+	point := [2]float64{x, y}
+	t.nearest(func(box []float64) float64 {
+		return boxSqDistanceToPoint(box, point[:])
+	}, sqDistanceLimit(maxDistance), func(leaf *rnode[float64, T], i int, _ float64) bool {
+		candidate := leaf.values[i]
+		if filter == nil || filter(candidate) {
+			result = append(result, candidate)
+		}
+		return k <= 0 || len(result) < k
+	})
+	return result
+}
+
+// GeoKnn returns the k items closest to the point (lon, lat) with their distance in kilometres, see RBush.GeoKnn
+func (t *Tree[T]) GeoKnn(lon, lat float64, k int, maxDistance float64, filter func(item T) bool) []GeoNeighbourOf[T] {
+	result := make([]GeoNeighbourOf[T], 0)
+	geoNearest(&t.rtree, lon, lat, maxGeoHaverSin(maxDistance), func(candidate T, h float64) bool {
+		if filter == nil || filter(candidate) {
+			result = append(result, GeoNeighbourOf[T]{Item: candidate, Distance: haverSinToDistance(h)})
+		}
+		return k <= 0 || len(result) < k
+	})
+	return result
+}
+
+// GeoWithin returns the items at most radius kilometres away from the point (lon, lat), see RBush.GeoWithin
+func (t *Tree[T]) GeoWithin(lon, lat, radius float64) []GeoNeighbourOf[T] {
+	result := make([]GeoNeighbourOf[T], 0)
+	if radius < 0 {
+		return result
+	}
+	geoNearest(&t.rtree, lon, lat, distanceToHaverSin(radius), func(item T, h float64) bool {
+		result = append(result, GeoNeighbourOf[T]{Item: item, Distance: haverSinToDistance(h)})
+		return true
+	})
+	return result
+}
+
+// SelfJoin calls fn once with every pair of different items whose bboxes intersect, see RBush.SelfJoin
+func (t *Tree[T]) SelfJoin(fn func(a, b T) bool) {
+	join(&t.rtree, &t.rtree, 1, true, fn)
+}
+
+// SelfJoinParallel is like SelfJoin with the concurrency of RBush.JoinParallel
+func (t *Tree[T]) SelfJoinParallel(workers int, fn func(a, b T) bool) {
+	join(&t.rtree, &t.rtree, workers, true, fn)
+}
+
+// JoinTrees calls fn with every pair of items of a and b whose bboxes intersect, see RBush.Join.
+// It is a function so that the trees can hold different types
+func JoinTrees[A, B any](a *Tree[A], b *Tree[B], fn func(a A, b B) bool) {
+	JoinTreesParallel(a, b, 1, fn)
+}
+
+// JoinTreesParallel calls fn concurrently from up to workers goroutines, see RBush.JoinParallel
+func JoinTreesParallel[A, B any](a *Tree[A], b *Tree[B], workers int, fn func(a A, b B) bool) {
+	join(&a.rtree, &b.rtree, workers, false, fn)
+}
+
+// All returns every item in the index
+func (t *Tree[T]) All() []T {
+	return t.rootNode.flattenDownwards()
+}
+
+func (t *Tree[T]) Clear() *Tree[T] {
+	if err := t.clear(); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Snapshot returns a read only copy of the tree that is not affected by later modifications, see RBush.Snapshot
+func (t *Tree[T]) Snapshot() *Tree[T] {
+	return &Tree[T]{toBBox: t.toBBox, rtree: t.snapshot()}
+}
+
+func (t *Tree[T]) ToBBox() BBox {
+	return flatBBox(t.rootNode.bbox)
+}
+
+// Check verifies the invariants of the index, see RBush.Check
+func (t *Tree[T]) Check() error {
+	return t.check(nil)
+}
+
+// read the values directly from the leaves
+func (t *Tree[T]) values(refs []itemRef) []T {
+	result := make([]T, len(refs))
+	for i, ref := range refs {
+		result[i] = ref.leaf.(*rnode[float64, T]).values[ref.index]
+	}
+	return result
+}
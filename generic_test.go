@@ -0,0 +1,93 @@
+package go_rbush
+
+import (
+	"sort"
+	"testing"
+)
+
+type vehicle struct {
+	id   int
+	x, y float64
+}
+
+func vehicleBBox(v vehicle) BBox {
+	return BBox{v.x, v.y, v.x, v.y}
+}
+
+func sameVehicle(a, b vehicle) bool {
+	return a.id == b.id
+}
+
+func getVehicles() []vehicle {
+	data := getDataExample()
+	vehicles := make([]vehicle, len(data))
+	for i, d := range data {
+		vehicles[i] = vehicle{id: i, x: d[0], y: d[1]}
+	}
+	return vehicles
+}
+
+func TestTree_LoadAndSearch(t *testing.T) {
+	vehicles := getVehicles()
+	tree := NewTreeWithOptions(vehicleBBox, Options{MAX_ENTRIES: 4}).Load(vehicles)
+	assertNoError(t, tree.Check())
+	result := tree.Search(BBox{40, 20, 80, 70})
+	assertEqual(t, len(result), 12, "")
+	for _, v := range result {
+		// values are returned untouched
+		assertEqual(t, v, vehicles[v.id], "")
+		assertEqual(t, BBox{40, 20, 80, 70}.contains(vehicleBBox(v)), true, "")
+	}
+	assertEqual(t, tree.Collides(BBox{40, 20, 80, 70}), true, "")
+	assertEqual(t, tree.Collides(BBox{200, 200, 210, 210}), false, "")
+	assertEqual(t, tree.ToBBox(), BBox{0, 0, 95, 95}, "")
+	// Load does not reorder the given slice
+	assertEqual(t, vehicles[10].id, 10, "")
+}
+
+func TestTree_InsertRemoveUpdate(t *testing.T) {
+	vehicles := getVehicles()
+	tree := NewTreeWithOptions(vehicleBBox, Options{MAX_ENTRIES: 4})
+	for _, v := range vehicles {
+		assertNoError(t, tree.Insert(v))
+	}
+	assertEqual(t, len(tree.All()), len(vehicles), "")
+
+	removed, err := tree.Remove(vehicles[3], sameVehicle)
+	assertNoError(t, err)
+	assertEqual(t, removed, true, "")
+	removed, _ = tree.Remove(vehicles[3], sameVehicle)
+	assertEqual(t, removed, false, "")
+
+	moved := vehicles[5]
+	moved.x, moved.y = 150, 150
+	updated, err := tree.Update(vehicles[5], moved, sameVehicle)
+	assertNoError(t, err)
+	assertEqual(t, updated, true, "")
+	assertEqual(t, len(tree.Search(BBox{140, 140, 160, 160})), 1, "")
+	assertEqual(t, tree.Search(BBox{140, 140, 160, 160})[0], moved, "")
+	assertNoError(t, tree.Check())
+
+	all := tree.All()
+	ids := make([]int, len(all))
+	for i, v := range all {
+		ids[i] = v.id
+	}
+	sort.Ints(ids)
+	assertEqual(t, len(ids), len(vehicles)-1, "")
+	assertEqual(t, ids[3], 4, "")
+
+	tree.Clear()
+	assertEqual(t, len(tree.All()), 0, "")
+}
+
+func TestTree_Knn(t *testing.T) {
+	tree := NewTree(vehicleBBox).Load(getVehicles())
+	result := tree.Knn(36, 37, 3, 0, nil)
+	assertEqual(t, len(result), 3, "")
+	assertEqual(t, result[0], vehicle{id: 10, x: 35, y: 35}, "")
+	result = tree.Knn(36, 37, 1, 0, func(v vehicle) bool {
+		return v.id != 10
+	})
+	assertEqual(t, result[0], vehicle{id: 11, x: 45, y: 45}, "")
+}
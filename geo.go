@@ -0,0 +1,143 @@
+package go_rbush
+
+import "math"
+
+// Geographic queries for indexes of WGS84 longitude and latitude, following geokdbush and geoflatbush.
+// Distances are great circle distances on a sphere, so they are right across the antimeridian and near the poles.
+// Internally they are compared as haversines, which grow with the distance, to avoid trigonometric inverses.
+//
+// Longitudes are in [-180, 180]. Queries wrap around the antimeridian, as the haversine is periodic in the
+// difference of longitudes a point at 179.9 is close to one at -179.9. Stored bboxes have to satisfy
+// MinX <= MaxX, so a bbox crossing the antimeridian is not supported and has to be split into two boxes,
+// one ending at 180 and the other starting at -180
+
+// EarthRadius is the mean radius of the Earth in kilometres used by the geographic queries
+const EarthRadius = 6371.0
+
+const rad = math.Pi / 180
+
+// GeoNeighbour is an item found by a geographic query, with its distance in kilometres
+type GeoNeighbour struct {
+	Item     Interface // Interface of length 1
+	Distance float64   // great circle distance to the closest point of the bbox of the item
+}
+
+// GeoNeighbourOf is an item of a Tree found by a geographic query, with its distance in kilometres
+type GeoNeighbourOf[T any] struct {
+	Item     T
+	Distance float64
+}
+
+// GeoKnn returns the k items closest to the point (lon, lat), ordered by great circle distance to their bbox.
+// As in Knn, k <= 0 returns all items, maxDistance <= 0 means no limit on distance and filter can be nil.
+// maxDistance is in kilometres
+func (r *RBush) GeoKnn(lon, lat float64, k int, maxDistance float64, filter func(item Interface) bool) []GeoNeighbour {
+	result := make([]GeoNeighbour, 0)
+	geoNearest(&r.rtree, lon, lat, maxGeoHaverSin(maxDistance), func(it item, h float64) bool {
+		candidate := it.value()
+		if filter == nil || filter(candidate) {
+			result = append(result, GeoNeighbour{Item: candidate, Distance: haverSinToDistance(h)})
+		}
+		return k <= 0 || len(result) < k
+	})
+	return result
+}
+
+// GeoWithin returns the items at most radius kilometres away from the point (lon, lat), ordered by distance
+func (r *RBush) GeoWithin(lon, lat, radius float64) []GeoNeighbour {
+	result := make([]GeoNeighbour, 0)
+	if radius < 0 {
+		return result
+	}
+	geoNearest(&r.rtree, lon, lat, distanceToHaverSin(radius), func(it item, h float64) bool {
+		result = append(result, GeoNeighbour{Item: it.value(), Distance: haverSinToDistance(h)})
+		return true
+	})
+	return result
+}
+
+// haversine limit of the queries, maxDistance <= 0 means no limit
+func maxGeoHaverSin(maxDistance float64) float64 {
+	if maxDistance > 0 {
+		return distanceToHaverSin(maxDistance)
+	}
+	return 1
+}
+
+// visit the items whose haversine is at most maxHaverSin in order of distance, until fn returns false
+func geoNearest[E any](t *rtree[float64, E], lon, lat, maxHaverSin float64, fn func(value E, haverSin float64) bool) {
+	cosLat := math.Cos(lat * rad)
+	// the distance of the queue holds the haversine of the distance
+	t.nearest(func(box []float64) float64 {
+		return boxHaverSin(lon, lat, cosLat, flatBBox(box))
+	}, maxHaverSin, func(leaf *rnode[float64, E], i int, h float64) bool {
+		return fn(leaf.values[i], h)
+	})
+}
+
+// GeoDistance returns the great circle distance in kilometres between two points given as longitude and latitude
+func GeoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	return haverSinToDistance(haverSinDistance(lon1, lat1, lon2, lat2, math.Cos(lat1*rad)))
+}
+
+// haversine of the distance from the point to the closest point of the box, 0 if the point is inside
+func boxHaverSin(lon, lat, cosLat float64, b BBox) float64 {
+	// the point is between the minimum and maximum longitudes, the closest point is right above or below
+	if lon >= b.MinX && lon <= b.MaxX {
+		if lat < b.MinY {
+			return haverSin((lat - b.MinY) * rad)
+		}
+		if lat > b.MaxY {
+			return haverSin((lat - b.MaxY) * rad)
+		}
+		return 0
+	}
+	// otherwise it is on the closest meridian of the box, at the latitude where the great circle to it is perpendicular
+	haverSinDLon := math.Min(haverSin((lon-b.MinX)*rad), haverSin((lon-b.MaxX)*rad))
+	extremumLat := vertexLat(lat, haverSinDLon)
+	if extremumLat > b.MinY && extremumLat < b.MaxY {
+		return haverSinDistancePartial(haverSinDLon, cosLat, lat, extremumLat)
+	}
+	// or at one of the corners
+	return math.Min(
+		haverSinDistancePartial(haverSinDLon, cosLat, lat, b.MinY),
+		haverSinDistancePartial(haverSinDLon, cosLat, lat, b.MaxY),
+	)
+}
+
+func haverSin(theta float64) float64 {
+	s := math.Sin(theta / 2)
+	return s * s
+}
+
+func haverSinDistance(lon1, lat1, lon2, lat2, cosLat1 float64) float64 {
+	return haverSinDistancePartial(haverSin((lon1-lon2)*rad), cosLat1, lat1, lat2)
+}
+
+func haverSinDistancePartial(haverSinDLon, cosLat1, lat1, lat2 float64) float64 {
+	return cosLat1*math.Cos(lat2*rad)*haverSinDLon + haverSin((lat1-lat2)*rad)
+}
+
+// latitude of the point of a meridian closest to the point at lat, given the haversine of the difference in longitude
+func vertexLat(lat, haverSinDLon float64) float64 {
+	cosDLon := 1 - 2*haverSinDLon
+	if cosDLon <= 0 {
+		if lat > 0 {
+			return 90
+		}
+		return -90
+	}
+	return math.Atan(math.Tan(lat*rad)/cosDLon) / rad
+}
+
+// distances of half the circumference or more include the whole sphere
+func distanceToHaverSin(distance float64) float64 {
+	if distance >= math.Pi*EarthRadius {
+		return 1
+	}
+	return haverSin(distance / EarthRadius)
+}
+
+func haverSinToDistance(h float64) float64 {
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
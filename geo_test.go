@@ -0,0 +1,135 @@
+package go_rbush
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// points spread over the whole sphere, uniformly in latitude so that poles are well represented
+func getGeoPoints(n int) bboxes {
+	data := make(bboxes, n)
+	for i := range data {
+		lon, lat := rand.Float64()*360-180, rand.Float64()*180-90
+		data[i] = [4]float64{lon, lat, lon, lat}
+	}
+	return data
+}
+
+func assertAlmostEqual(t *testing.T, a, b float64) {
+	t.Helper()
+	assertEqual(t, math.Abs(a-b) < 1e-6, true, "")
+}
+
+func TestRBush_GeoKnnMatchesBruteForce(t *testing.T) {
+	data := getGeoPoints(5000)
+	tree := New().Load(append(bboxes{}, data...))
+	queries := [][2]float64{{179.9, 0}, {-180, 45}, {0, 89.99}, {120, -89.5}, {-73.9, 40.7}}
+	for i := 0; i < 20; i++ {
+		queries = append(queries, [2]float64{rand.Float64()*360 - 180, rand.Float64()*180 - 90})
+	}
+	for _, q := range queries {
+		distances := make([]float64, len(data))
+		for i, d := range data {
+			distances[i] = GeoDistance(q[0], q[1], d[0], d[1])
+		}
+		sort.Float64s(distances)
+
+		result := tree.GeoKnn(q[0], q[1], 20, 0, nil)
+		assertEqual(t, len(result), 20, "")
+		for i, r := range result {
+			b := interfaceBBox(r.Item)
+			assertAlmostEqual(t, r.Distance, distances[i])
+			assertAlmostEqual(t, GeoDistance(q[0], q[1], b.MinX, b.MinY), r.Distance)
+		}
+
+		radius := 500.0
+		within := tree.GeoWithin(q[0], q[1], radius)
+		expected := sort.SearchFloat64s(distances, radius+1e-9)
+		assertEqual(t, len(within), expected, "")
+		for i := 1; i < len(within); i++ {
+			assertEqual(t, within[i-1].Distance <= within[i].Distance, true, "Results should be ordered by distance")
+		}
+		assertEqual(t, len(tree.GeoKnn(q[0], q[1], 0, radius, nil)), expected, "")
+	}
+}
+
+func TestRBush_GeoKnnAcrossAntimeridianAndPoles(t *testing.T) {
+	data := bboxes{
+		{-179.9, 0, -179.9, 0},
+		{170, 0, 170, 0},
+		{0, 89.9, 0, 89.9},
+		{180, 89.9, 180, 89.9},
+		{90, 80, 90, 80},
+	}
+	tree := New().Load(append(bboxes{}, data...))
+
+	// planar distance would pick the point at 170
+	result := tree.GeoKnn(179.9, 0, 1, 0, nil)
+	assertEqual(t, interfaceBBox(result[0].Item), BBox{-179.9, 0, -179.9, 0}, "")
+	assertAlmostEqual(t, result[0].Distance, GeoDistance(179.9, 0, -179.9, 0))
+	assertEqual(t, result[0].Distance < 23, true, "")
+
+	// both points close to the north pole are about 22 km apart
+	result = tree.GeoKnn(0, 89.9, 2, 0, nil)
+	assertEqual(t, interfaceBBox(result[1].Item), BBox{180, 89.9, 180, 89.9}, "")
+	assertEqual(t, result[1].Distance > 22 && result[1].Distance < 23, true, "")
+	assertEqual(t, len(tree.GeoWithin(0, 89.9, 23)), 2, "")
+	assertEqual(t, len(tree.GeoWithin(0, 89.9, 1200)), 3, "")
+	assertEqual(t, len(tree.GeoWithin(0, 0, 30000)), len(data), "")
+	assertEqual(t, len(tree.GeoWithin(0, 0, -1)), 0, "")
+
+	onlyEast := tree.GeoKnn(179.9, 0, 1, 0, func(item Interface) bool {
+		return interfaceBBox(item).MinX > 0
+	})
+	assertEqual(t, interfaceBBox(onlyEast[0].Item), BBox{170, 0, 170, 0}, "")
+	assertEqual(t, len(New().GeoKnn(0, 0, 1, 0, nil)), 0, "")
+}
+
+// distance to a box is the distance to its closest point
+func TestBoxHaverSin(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		lon, lat := rand.Float64()*360-180, rand.Float64()*180-90
+		minLon, minLat := rand.Float64()*340-180, rand.Float64()*160-90
+		b := BBox{minLon, minLat, minLon + rand.Float64()*20, minLat + rand.Float64()*20}
+		bound := haverSinToDistance(boxHaverSin(lon, lat, math.Cos(lat*rad), b))
+		closest := math.Inf(1)
+		// sample the border of the box, the closest point is in the border if the point is outside
+		for s := 0.0; s <= 1; s += 1.0 / 2000 {
+			x, y := b.MinX+s*(b.MaxX-b.MinX), b.MinY+s*(b.MaxY-b.MinY)
+			closest = math.Min(closest, math.Min(
+				math.Min(GeoDistance(lon, lat, x, b.MinY), GeoDistance(lon, lat, x, b.MaxY)),
+				math.Min(GeoDistance(lon, lat, b.MinX, y), GeoDistance(lon, lat, b.MaxX, y)),
+			))
+		}
+		if b.contains(BBox{lon, lat, lon, lat}) {
+			closest = 0
+		}
+		assertEqual(t, bound <= closest+1e-6, true, "Distance to the box should be a lower bound")
+		assertEqual(t, closest-bound < 2, true, "Distance to the box should be close to the sampled one")
+	}
+}
+
+func TestTree_GeoKnn(t *testing.T) {
+	type city struct {
+		name     string
+		lon, lat float64
+	}
+	cities := []city{{"Suva", 178.44, -18.14}, {"Apia", -171.77, -13.83}, {"Nuku'alofa", -175.2, -21.14}, {"Madrid", -3.7, 40.42}}
+	tree := NewTree(func(c city) BBox {
+		return BBox{c.lon, c.lat, c.lon, c.lat}
+	}).Load(cities)
+
+	// closest ones are across the antimeridian
+	result := tree.GeoKnn(179.9, -20, 2, 0, nil)
+	assertEqual(t, len(result), 2, "")
+	assertEqual(t, result[0].Item.name, "Suva", "")
+	assertEqual(t, result[1].Item.name, "Nuku'alofa", "")
+	assertAlmostEqual(t, result[1].Distance, GeoDistance(179.9, -20, -175.2, -21.14))
+
+	notSuva := tree.GeoKnn(179.9, -20, 1, 0, func(c city) bool { return c.name != "Suva" })
+	assertEqual(t, notSuva[0].Item.name, "Nuku'alofa", "")
+	assertEqual(t, len(tree.GeoWithin(179.9, -20, 1500)), 3, "")
+	assertEqual(t, len(tree.GeoWithin(179.9, -20, -1)), 0, "")
+}
@@ -0,0 +1,27 @@
+//go:build go1.23
+
+package go_rbush
+
+import "iter"
+
+// SearchSeq returns an iterator over the items intersecting the bbox, see SearchFunc.
+// Breaking out of the loop stops the search. The index must not be modified while iterating
+func (r *RBush) SearchSeq(b BBox) iter.Seq[Interface] {
+	return func(yield func(Interface) bool) {
+		r.SearchFunc(b, yield)
+	}
+}
+
+// SearchSeq returns an iterator over the items intersecting the bbox, see RBush.SearchSeq
+func (t *Tree[T]) SearchSeq(b BBox) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.SearchFunc(b, yield)
+	}
+}
+
+// SearchSeq holds the read lock while iterating, so the loop must not modify the index
+func (s *SyncRBush) SearchSeq(b BBox) iter.Seq[Interface] {
+	return func(yield func(Interface) bool) {
+		s.SearchFunc(b, yield)
+	}
+}
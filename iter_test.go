@@ -0,0 +1,44 @@
+//go:build go1.23
+
+package go_rbush
+
+import "testing"
+
+func TestRBush_SearchSeq(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	b := BBox{40, 20, 80, 70}
+	expected := tree.SearchItems(b)
+	i := 0
+	for p := range tree.SearchSeq(b) {
+		assertEqual(t, p.(bboxes)[0], expected[i].(bboxes)[0], "")
+		i++
+	}
+	assertEqual(t, i, len(expected), "")
+
+	visited := 0
+	for range tree.SearchSeq(BBox{0, 0, 100, 100}) {
+		visited++
+		if visited == 5 {
+			break
+		}
+	}
+	assertEqual(t, visited, 5, "")
+}
+
+func TestTree_SearchSeq(t *testing.T) {
+	vehicles := getVehicles()
+	tree := NewTreeWithOptions(vehicleBBox, Options{MAX_ENTRIES: 4}).Load(vehicles)
+	b := BBox{40, 20, 80, 70}
+	found := 0
+	for v := range tree.SearchSeq(b) {
+		assertEqual(t, v, vehicles[v.id], "")
+		found++
+	}
+	assertEqual(t, found, len(tree.Search(b)), "")
+	allocs := testing.AllocsPerRun(10, func() {
+		for v := range tree.SearchSeq(b) {
+			found += v.id
+		}
+	})
+	assertEqual(t, allocs, 0.0, "SearchSeq should not allocate")
+}
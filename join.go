@@ -0,0 +1,208 @@
+package go_rbush
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Spatial join by synchronized traversal of both trees: only pairs of nodes whose bboxes intersect are visited,
+// descending the higher node of the pair, or both of them when they are at the same height
+
+type joiner[C Coordinate, A, B any] struct {
+	fn      func(a A, b B) bool
+	self    bool // both trees are the same one, each pair of different items is visited once
+	stopped atomic.Bool
+}
+
+// pair of nodes to join
+type joinTask[C Coordinate, A, B any] struct {
+	a *rnode[C, A]
+	b *rnode[C, B]
+}
+
+// Join calls fn with every pair of items a of r and b of other whose bboxes intersect, until fn returns false.
+// Joining r with itself or with a snapshot of it also pairs every item with itself, see SelfJoin. fn must not modify the indexes
+func (r *RBush) Join(other *RBush, fn func(a, b Interface) bool) {
+	join(&r.rtree, &other.rtree, 1, false, func(a, b item) bool {
+		return fn(a.value(), b.value())
+	})
+}
+
+// JoinParallel is like Join but pairs are visited by up to workers goroutines, GOMAXPROCS if workers is 0.
+// fn is called concurrently and in no particular order. Once fn returns false no new calls are started
+func (r *RBush) JoinParallel(other *RBush, workers int, fn func(a, b Interface) bool) {
+	join(&r.rtree, &other.rtree, workers, false, func(a, b item) bool {
+		return fn(a.value(), b.value())
+	})
+}
+
+// SelfJoin calls fn with every pair of different items of r whose bboxes intersect, until fn returns false.
+// Each pair is visited once, in no particular order of a and b
+func (r *RBush) SelfJoin(fn func(a, b Interface) bool) {
+	join(&r.rtree, &r.rtree, 1, true, func(a, b item) bool {
+		return fn(a.value(), b.value())
+	})
+}
+
+// SelfJoinParallel is like SelfJoin with the concurrency of JoinParallel
+func (r *RBush) SelfJoinParallel(workers int, fn func(a, b Interface) bool) {
+	join(&r.rtree, &r.rtree, workers, true, func(a, b item) bool {
+		return fn(a.value(), b.value())
+	})
+}
+
+// self is only set by self joins, joining two trees that share nodes, such as a tree and its snapshot,
+// or a tree with itself through Join visits every pair
+func join[C Coordinate, A, B any](ta *rtree[C, A], tb *rtree[C, B], workers int, self bool, fn func(a A, b B) bool) {
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	j := &joiner[C, A, B]{fn: fn, self: self}
+	a, b := ta.rootNode, tb.rootNode
+	if workers == 1 {
+		if j.same(a, b) || boxIntersects(a.bbox, b.bbox) {
+			j.join(a, b)
+		}
+		return
+	}
+	// split the join into enough pairs of nodes to keep every worker busy
+	tasks := []joinTask[C, A, B]{}
+	if j.same(a, b) || boxIntersects(a.bbox, b.bbox) {
+		tasks = append(tasks, joinTask[C, A, B]{a, b})
+	}
+	for len(tasks) < 4*workers {
+		next := make([]joinTask[C, A, B], 0, len(tasks))
+		expanded := false
+		for _, t := range tasks {
+			if t.a.isLeaf && t.b.isLeaf {
+				next = append(next, t)
+				continue
+			}
+			expanded = true
+			j.expand(t.a, t.b, func(a *rnode[C, A], b *rnode[C, B]) bool {
+				next = append(next, joinTask[C, A, B]{a, b})
+				return true
+			})
+		}
+		tasks = next
+		if !expanded {
+			break
+		}
+	}
+
+	queue := make(chan joinTask[C, A, B])
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(tasks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				j.join(t.a, t.b)
+			}
+		}()
+	}
+	for _, t := range tasks {
+		if j.stopped.Load() {
+			break
+		}
+		queue <- t
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// tells whether a self join is pairing a node with itself, so pairs of its items are only visited in one order
+func (j *joiner[C, A, B]) same(a *rnode[C, A], b *rnode[C, B]) bool {
+	return j.self && interface{}(a) == interface{}(b)
+}
+
+// visit the pairs of intersecting items below a and b, that intersect with each other.
+// If a and b are the same node each pair of different items is visited once. Returns false if the join was stopped
+func (j *joiner[C, A, B]) join(a *rnode[C, A], b *rnode[C, B]) bool {
+	if j.stopped.Load() {
+		return false
+	}
+	if a.isLeaf && b.isLeaf {
+		return j.joinLeaves(a, b)
+	}
+	return j.expand(a, b, j.join)
+}
+
+func (j *joiner[C, A, B]) joinLeaves(a *rnode[C, A], b *rnode[C, B]) bool {
+	self := j.same(a, b)
+	for i, itA := range a.values {
+		boxA := a.entryBox(i)
+		if self {
+			for k := i + 1; k < len(b.values); k++ {
+				if boxIntersects(boxA, b.entryBox(k)) && !j.emit(itA, b.values[k]) {
+					return false
+				}
+			}
+			continue
+		}
+		if !boxIntersects(b.bbox, boxA) {
+			continue
+		}
+		for k, itB := range b.values {
+			if boxIntersects(boxA, b.entryBox(k)) && !j.emit(itA, itB) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// no new calls to fn are started once any of the workers has stopped the join
+func (j *joiner[C, A, B]) emit(a A, b B) bool {
+	if j.stopped.Load() {
+		return false
+	}
+	if !j.fn(a, b) {
+		j.stopped.Store(true)
+		return false
+	}
+	return true
+}
+
+// calls visit with the pairs of intersecting entries one level below a and b. The higher node is descended,
+// or both if they are at the same height. If a and b are the same node pairs of children are only visited in one order
+func (j *joiner[C, A, B]) expand(a *rnode[C, A], b *rnode[C, B], visit func(a *rnode[C, A], b *rnode[C, B]) bool) bool {
+	switch {
+	case j.same(a, b):
+		for i, ca := range a.children {
+			if !visit(ca, b.children[i]) {
+				return false
+			}
+			for _, cb := range b.children[i+1:] {
+				if boxIntersects(ca.bbox, cb.bbox) && !visit(ca, cb) {
+					return false
+				}
+			}
+		}
+	case a.height > b.height:
+		for _, ca := range a.children {
+			if boxIntersects(ca.bbox, b.bbox) && !visit(ca, b) {
+				return false
+			}
+		}
+	case a.height < b.height:
+		for _, cb := range b.children {
+			if boxIntersects(a.bbox, cb.bbox) && !visit(a, cb) {
+				return false
+			}
+		}
+	default:
+		for _, ca := range a.children {
+			if !boxIntersects(ca.bbox, b.bbox) {
+				continue
+			}
+			for _, cb := range b.children {
+				if boxIntersects(ca.bbox, cb.bbox) && !visit(ca, cb) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package go_rbush
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type bboxPair [2]BBox
+
+func bruteForceJoin(a, b bboxes) map[bboxPair]bool {
+	pairs := map[bboxPair]bool{}
+	for _, x := range a {
+		for _, y := range b {
+			bx, by := BBox{x[0], x[1], x[2], x[3]}, BBox{y[0], y[1], y[2], y[3]}
+			if bx.intersects(by) {
+				pairs[bboxPair{bx, by}] = true
+			}
+		}
+	}
+	return pairs
+}
+
+// collects the pairs visited by join, failing on duplicates. Safe for concurrent use
+func collectPairs(t *testing.T, join func(fn func(a, b Interface) bool)) map[bboxPair]bool {
+	pairs := map[bboxPair]bool{}
+	var mu sync.Mutex
+	join(func(a, b Interface) bool {
+		pair := bboxPair{interfaceBBox(a), interfaceBBox(b)}
+		mu.Lock()
+		defer mu.Unlock()
+		assertEqual(t, pairs[pair], false, "Pairs should be visited once")
+		pairs[pair] = true
+		return true
+	})
+	return pairs
+}
+
+func assertSamePairs(t *testing.T, result, expected map[bboxPair]bool) {
+	assertEqual(t, len(result), len(expected), "")
+	for p := range expected {
+		assertEqual(t, result[p], true, "")
+	}
+}
+
+func TestRBush_JoinMatchesBruteForce(t *testing.T) {
+	parcels := getData(2000, 3)
+	cases := []bboxes{getData(500, 5), getData(5, 20), getData(3000, 1), {}}
+	for _, zones := range cases {
+		expected := bruteForceJoin(parcels, zones)
+		// different node sizes so the trees have different heights
+		a := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(append(bboxes{}, parcels...))
+		b := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, zones...))
+		assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { a.Join(b, fn) }), expected)
+		assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { a.JoinParallel(b, 4, fn) }), expected)
+
+		reversed := map[bboxPair]bool{}
+		for p := range expected {
+			reversed[bboxPair{p[1], p[0]}] = true
+		}
+		assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { b.Join(a, fn) }), reversed)
+		assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { b.JoinParallel(a, 0, fn) }), reversed)
+	}
+}
+
+func TestRBush_SelfJoin(t *testing.T) {
+	data := getData(2000, 2)
+	expected := map[bboxPair]bool{}
+	for i, x := range data {
+		for _, y := range data[i+1:] {
+			bx, by := BBox{x[0], x[1], x[2], x[3]}, BBox{y[0], y[1], y[2], y[3]}
+			if bx.intersects(by) {
+				expected[bboxPair{bx, by}] = true
+			}
+		}
+	}
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	for _, join := range []func(fn func(a, b Interface) bool){tree.SelfJoin, func(fn func(a, b Interface) bool) { tree.SelfJoinParallel(4, fn) }} {
+		result := collectPairs(t, join)
+		assertEqual(t, len(result), len(expected), "")
+		for p := range result {
+			assertEqual(t, p[0] != p[1], true, "Items should not be joined with themselves")
+			assertEqual(t, expected[p] || expected[bboxPair{p[1], p[0]}], true, "")
+		}
+	}
+	count := 0
+	New().SelfJoin(func(a, b Interface) bool {
+		count++
+		return true
+	})
+	assertEqual(t, count, 0, "")
+}
+
+// snapshots share nodes with their tree, which must not turn the join into a self join
+func TestRBush_JoinWithSnapshot(t *testing.T) {
+	data := getData(500, 5)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(append(bboxes{}, data...))
+	snapshot := tree.Snapshot()
+	expected := bruteForceJoin(data, data)
+	assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { tree.Join(snapshot, fn) }), expected)
+	assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { tree.JoinParallel(snapshot, 4, fn) }), expected)
+	assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { tree.Join(tree, fn) }), expected)
+
+	inserted := bboxes{{40, 40, 60, 60}}
+	assertNoError(t, tree.InsertElement(inserted))
+	expected = bruteForceJoin(append(append(bboxes{}, data...), inserted...), data)
+	assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { tree.Join(snapshot, fn) }), expected)
+	assertSamePairs(t, collectPairs(t, func(fn func(a, b Interface) bool) { tree.JoinParallel(snapshot, 4, fn) }), expected)
+}
+
+func TestRBush_JoinStops(t *testing.T) {
+	a := New().Load(getData(1000, 5))
+	b := New().Load(getData(1000, 5))
+	total := len(bruteForceJoin(getTreePointsAsCoordinates(a.rootNode), getTreePointsAsCoordinates(b.rootNode)))
+	count := 0
+	a.Join(b, func(x, y Interface) bool {
+		count++
+		return count < 10
+	})
+	assertEqual(t, count, 10, "")
+
+	var parallelCount atomic.Int64
+	a.JoinParallel(b, 4, func(x, y Interface) bool {
+		return parallelCount.Add(1) < 10
+	})
+	assertEqual(t, parallelCount.Load() < int64(total), true, "")
+
+	count = 0
+	a.SelfJoin(func(x, y Interface) bool {
+		count++
+		return false
+	})
+	assertEqual(t, count, 1, "")
+}
+
+func TestJoinTrees(t *testing.T) {
+	vehicles := getVehicles()
+	zones := []BBox{{0, 0, 20, 20}, {50, 50, 60, 60}, {200, 200, 300, 300}}
+	tree := NewTreeWithOptions(vehicleBBox, Options{MAX_ENTRIES: 4}).Load(vehicles)
+	zoneTree := NewTree(identityBBox).Load(zones)
+	inZone := map[BBox]int{}
+	JoinTrees(tree, zoneTree, func(v vehicle, zone BBox) bool {
+		assertEqual(t, zone.contains(vehicleBBox(v)), true, "")
+		inZone[zone]++
+		return true
+	})
+	for _, zone := range zones {
+		assertEqual(t, inZone[zone], len(tree.Search(zone)), "")
+	}
+
+	pairs := 0
+	tree.SelfJoinParallel(2, func(a, b vehicle) bool {
+		assertEqual(t, a.id != b.id, true, "")
+		return true
+	})
+	tree.SelfJoin(func(a, b vehicle) bool {
+		assertEqual(t, a.x == b.x && a.y == b.y, true, "")
+		pairs++
+		return true
+	})
+	duplicates := 0
+	for i, a := range vehicles {
+		for _, b := range vehicles[i+1:] {
+			if a.x == b.x && a.y == b.y {
+				duplicates++
+			}
+		}
+	}
+	assertEqual(t, pairs, duplicates, "")
+}
+
+// other workers see the stop of the first call, even in the middle of a pair of leaves
+func TestJoiner_NoCallsAfterStop(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(getData(1000, 20))
+	leaves := []*rbushNode{}
+	for nodes := []*rbushNode{tree.rootNode}; len(nodes) != 0; nodes = nodes[1:] {
+		if nodes[0].isLeaf {
+			leaves = append(leaves, nodes[0])
+		}
+		nodes = append(nodes, nodes[0].children...)
+	}
+	calls := 0
+	j := &joiner[float64, item, item]{self: true, fn: func(a, b item) bool {
+		calls++
+		return false
+	}}
+	assertEqual(t, j.joinLeaves(leaves[0], leaves[0]), false, "")
+	assertEqual(t, calls, 1, "")
+	for _, l := range leaves {
+		j.joinLeaves(l, l)
+		j.joinLeaves(leaves[0], l)
+	}
+	assertEqual(t, calls, 1, "There should be no calls after the join was stopped")
+}
@@ -0,0 +1,191 @@
+package go_rbush
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// ItemCodec converts items to and from JSON, so any payload can be stored with the tree.
+// Items are Interfaces of length 1, as returned by SearchItems
+type ItemCodec interface {
+	EncodeItem(item Interface) (json.RawMessage, error)
+	DecodeItem(data json.RawMessage) (Interface, error)
+}
+
+// BBoxCodec stores items as {"minX": .., "minY": .., "maxX": .., "maxY": ..} which is the default item format of rbush.
+// Items are decoded as BBoxes. Infinite coordinates are not valid JSON, they are written as null
+type BBoxCodec struct{}
+
+// BBoxes implements Interface for a slice of bboxes
+type BBoxes []BBox
+
+func (b BBoxes) GetBBoxAt(i int) (x1, y1, x2, y2 float64) {
+	return b[i].MinX, b[i].MinY, b[i].MaxX, b[i].MaxY
+}
+
+func (b BBoxes) Len() int {
+	return len(b)
+}
+
+func (b BBoxes) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b BBoxes) Slice(i, j int) Interface {
+	return b[i:j]
+}
+
+// Same layout as the nodes of rbush toJSON
+type jsonNode struct {
+	Children []json.RawMessage `json:"children"`
+	Height   int               `json:"height"`
+	Leaf     bool              `json:"leaf"`
+	jsonBBox
+}
+
+type jsonBBox struct {
+	MinX *float64 `json:"minX"`
+	MinY *float64 `json:"minY"`
+	MaxX *float64 `json:"maxX"`
+	MaxY *float64 `json:"maxY"`
+}
+
+// ToJSON encodes the whole tree in the format of rbush toJSON, items are encoded with the codec
+func (r *RBush) ToJSON(codec ItemCodec) ([]byte, error) {
+	return encodeJSONNode(r.rootNode, codec)
+}
+
+// FromJSON replaces the content of the index with a tree encoded by ToJSON or by rbush toJSON.
+// Bboxes of the nodes are recomputed from the decoded items. Nodes can have at most MAX_ENTRIES entries
+// and only the root can be empty. As in rbush bulk loaded nodes might have less than MIN_ENTRIES, so it is not checked
+func (r *RBush) FromJSON(data []byte, codec ItemCodec) error {
+	if err := r.checkWritable(); err != nil {
+		return err
+	}
+	root, err := decodeJSONNode(data, codec, r.cow, r.options.MAX_ENTRIES)
+	if err != nil {
+		return err
+	}
+	if root.numEntries() == 0 {
+		r.initRootNode()
+		return nil
+	}
+	r.rootNode = root
+	return nil
+}
+
+func encodeJSONNode(n *rbushNode, codec ItemCodec) ([]byte, error) {
+	jn := jsonNode{
+		Children: make([]json.RawMessage, 0, n.numEntries()),
+		Height:   n.height,
+		Leaf:     n.isLeaf,
+		jsonBBox: newJSONBBox(flatBBox(n.bbox)),
+	}
+	for _, it := range n.values {
+		encoded, err := codec.EncodeItem(it.value())
+		if err != nil {
+			return nil, err
+		}
+		jn.Children = append(jn.Children, encoded)
+	}
+	for _, c := range n.children {
+		encoded, err := encodeJSONNode(c, codec)
+		if err != nil {
+			return nil, err
+		}
+		jn.Children = append(jn.Children, encoded)
+	}
+	return json.Marshal(jn)
+}
+
+func decodeJSONNode(data []byte, codec ItemCodec, cow *copyOnWrite, maxEntries int) (*rbushNode, error) {
+	var jn jsonNode
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return nil, err
+	}
+	if jn.Leaf != (jn.Height == 1) {
+		return nil, fmt.Errorf("rbush: invalid json, node of height %v with leaf %v", jn.Height, jn.Leaf)
+	}
+	if len(jn.Children) > maxEntries {
+		return nil, fmt.Errorf("rbush: invalid json, node of height %v has %v children, more than max entries %v", jn.Height, len(jn.Children), maxEntries)
+	}
+	n := &rbushNode{
+		bbox:   make([]float64, 4),
+		height: jn.Height,
+		isLeaf: jn.Leaf,
+		cow:    cow,
+	}
+	if n.isLeaf {
+		n.coordinates = make([]float64, 0, 4*len(jn.Children))
+		n.values = make([]item, len(jn.Children))
+		for i, c := range jn.Children {
+			p, err := codec.DecodeItem(c)
+			if err != nil {
+				return nil, err
+			}
+			b := interfaceBBox(p)
+			n.coordinates = append(n.coordinates, b.MinX, b.MinY, b.MaxX, b.MaxY)
+			n.values[i] = item{points: p}
+		}
+	} else {
+		n.children = make([]*rbushNode, len(jn.Children))
+		for i, c := range jn.Children {
+			child, err := decodeJSONNode(c, codec, cow, maxEntries)
+			if err != nil {
+				return nil, err
+			}
+			if child.numEntries() == 0 {
+				return nil, fmt.Errorf("rbush: invalid json, node of height %v has an empty child", n.height)
+			}
+			if child.height != n.height-1 {
+				return nil, fmt.Errorf("rbush: invalid json, node of height %v has a child of height %v", n.height, child.height)
+			}
+			n.children[i] = child
+		}
+	}
+	n.updateBBox()
+	return n, nil
+}
+
+func (BBoxCodec) EncodeItem(item Interface) (json.RawMessage, error) {
+	return json.Marshal(newJSONBBox(interfaceBBox(item)))
+}
+
+// Missing or null coordinates are decoded as infinite, so the box is unbounded in that direction
+func (BBoxCodec) DecodeItem(data json.RawMessage) (Interface, error) {
+	var jb jsonBBox
+	if err := json.Unmarshal(data, &jb); err != nil {
+		return nil, err
+	}
+	return BBoxes{{
+		MinX: fromJSONFloat(jb.MinX, math.Inf(-1)),
+		MinY: fromJSONFloat(jb.MinY, math.Inf(-1)),
+		MaxX: fromJSONFloat(jb.MaxX, math.Inf(+1)),
+		MaxY: fromJSONFloat(jb.MaxY, math.Inf(+1)),
+	}}, nil
+}
+
+func newJSONBBox(b BBox) jsonBBox {
+	return jsonBBox{
+		MinX: toJSONFloat(b.MinX),
+		MinY: toJSONFloat(b.MinY),
+		MaxX: toJSONFloat(b.MaxX),
+		MaxY: toJSONFloat(b.MaxY),
+	}
+}
+
+// same as JSON.stringify, non finite numbers become null
+func toJSONFloat(f float64) *float64 {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil
+	}
+	return &f
+}
+
+func fromJSONFloat(f *float64, null float64) float64 {
+	if f == nil {
+		return null
+	}
+	return *f
+}
@@ -0,0 +1,122 @@
+package go_rbush
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+// tree in the layout of rbush toJSON in javascript, items use the default format
+const rbushJSON = `{"children":[
+{"children":[{"minX":0,"minY":0,"maxX":0,"maxY":0},{"minX":10,"minY":10,"maxX":10,"maxY":10},{"minX":20,"minY":20,"maxX":20,"maxY":20}],"height":1,"leaf":true,"minX":0,"minY":0,"maxX":20,"maxY":20},
+{"children":[{"minX":25,"minY":0,"maxX":25,"maxY":0},{"minX":35,"minY":10,"maxX":35,"maxY":10},{"minX":45,"minY":20,"maxX":45,"maxY":20}],"height":1,"leaf":true,"minX":25,"minY":0,"maxX":45,"maxY":20}
+],"height":2,"leaf":false,"minX":0,"minY":0,"maxX":45,"maxY":20}`
+
+func TestRBush_FromJSONOfJavascriptTree(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4})
+	assertNoError(t, tree.FromJSON([]byte(rbushJSON), BBoxCodec{}))
+	assertNoError(t, tree.Check())
+	assertEqual(t, tree.rootNode.height, 2, "")
+	assertEqual(t, tree.ToBBox(), BBox{0, 0, 45, 20}, "")
+	assertEqual(t, len(tree.Search(BBox{0, 0, 30, 30})), 4, "")
+	// tree keeps working after decoding
+	assertNoError(t, tree.InsertElement(BBoxes{{50, 50, 50, 50}}))
+	assertEqual(t, tree.ToBBox(), BBox{0, 0, 50, 50}, "")
+}
+
+func TestRBush_JSONRoundTrip(t *testing.T) {
+	data := getData(1000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data...))
+	encoded, err := tree.ToJSON(BBoxCodec{})
+	assertNoError(t, err)
+
+	decoded := NewWithOptions(Options{MAX_ENTRIES: 9})
+	assertNoError(t, decoded.FromJSON(encoded, BBoxCodec{}))
+	assertNoError(t, decoded.Check())
+	assertEqual(t, decoded.rootNode.height, tree.rootNode.height, "")
+	assertEqual(t, decoded.ToBBox(), tree.ToBBox(), "")
+	for _, q := range getData(50, 10) {
+		b := BBox{q[0], q[1], q[2], q[3]}
+		assertEqual(t, len(decoded.Search(b)), len(tree.Search(b)), "")
+	}
+
+	// encoding is stable
+	reencoded, err := decoded.ToJSON(BBoxCodec{})
+	assertNoError(t, err)
+	assertEqual(t, string(reencoded), string(encoded), "")
+}
+
+func TestRBush_JSONEmptyTree(t *testing.T) {
+	encoded, err := New().ToJSON(BBoxCodec{})
+	assertNoError(t, err)
+	assertEqual(t, string(encoded), `{"children":[],"height":1,"leaf":true,"minX":null,"minY":null,"maxX":null,"maxY":null}`, "")
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	assertNoError(t, tree.FromJSON(encoded, BBoxCodec{}))
+	assertEqual(t, len(tree.All()), 0, "")
+	assertNoError(t, tree.Check())
+}
+
+func TestRBush_JSONInvalid(t *testing.T) {
+	tree := New()
+	if tree.FromJSON([]byte(`{"children":[],"height":2,"leaf":true}`), BBoxCodec{}) == nil {
+		t.Error("Leaf of height 2 should not be accepted")
+	}
+	if tree.FromJSON([]byte(`{"children":[{"children":[],"height":3,"leaf":false}],"height":2,"leaf":false}`), BBoxCodec{}) == nil {
+		t.Error("Wrong heights should not be accepted")
+	}
+	if tree.FromJSON([]byte(`{"children":`), BBoxCodec{}) == nil {
+		t.Error("Invalid json should not be accepted")
+	}
+	if tree.FromJSON([]byte(`{"children":[{"children":[],"height":1,"leaf":true}],"height":2,"leaf":false}`), BBoxCodec{}) == nil {
+		t.Error("Empty nodes below the root should not be accepted")
+	}
+	// leaves of rbushJSON have 3 items
+	small := NewWithOptions(Options{MAX_ENTRIES: 2})
+	if small.FromJSON([]byte(rbushJSON), BBoxCodec{}) == nil {
+		t.Error("Nodes with more than max entries should not be accepted")
+	}
+	assertEqual(t, len(small.All()), 0, "Index should be left unchanged")
+}
+
+type featureCodec struct{}
+
+type jsonFeature struct {
+	ID   int        `json:"id"`
+	BBox [4]float64 `json:"bbox"`
+}
+
+func (featureCodec) EncodeItem(item Interface) (json.RawMessage, error) {
+	f := item.(featureList)[0]
+	return json.Marshal(jsonFeature{ID: f.id, BBox: f.bbox})
+}
+
+func (featureCodec) DecodeItem(data json.RawMessage) (Interface, error) {
+	var f jsonFeature
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return featureList{{id: f.ID, bbox: f.BBox}}, nil
+}
+
+func TestRBush_JSONCustomCodec(t *testing.T) {
+	data := getDataExample()
+	features := make(featureList, len(data))
+	for i, d := range data {
+		features[i] = feature{id: i, bbox: d}
+	}
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(features)
+	encoded, err := tree.ToJSON(featureCodec{})
+	assertNoError(t, err)
+	decoded := NewWithOptions(Options{MAX_ENTRIES: 4})
+	assertNoError(t, decoded.FromJSON(encoded, featureCodec{}))
+
+	ids := make([]int, 0)
+	for _, item := range decoded.SearchItems(BBox{40, 20, 80, 70}) {
+		f := item.(featureList)[0]
+		assertEqual(t, f.bbox, data[f.id], "")
+		ids = append(ids, f.id)
+	}
+	assertEqual(t, len(ids), 12, "")
+	sort.Ints(ids)
+	assertEqual(t, ids[0], 5, "")
+}
@@ -0,0 +1,106 @@
+package go_rbush
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Knn returns the k items closest to the point (x, y), ordered by distance. Distance is measured to the bounding box of the items.
+// Following rbush-knn, k <= 0 returns all items and maxDistance <= 0 means no limit on distance.
+// filter allows to skip items, it can be nil
+func (r *RBush) Knn(x, y float64, k int, maxDistance float64, filter func(item Interface) bool) []Interface {
+	result := make([]Interface, 0)
+	point := [2]float64{x, y}
+	r.nearest(func(box []float64) float64 {
+		return boxSqDistanceToPoint(box, point[:])
+	}, sqDistanceLimit(maxDistance), func(leaf *rbushNode, i int, _ float64) bool {
+		candidate := leaf.values[i].value()
+		if filter == nil || filter(candidate) {
+			result = append(result, candidate)
+		}
+		return k <= 0 || len(result) < k
+	})
+	return result
+}
+
+// limit of the squared distance of the queries, maxDistance <= 0 means no limit
+func sqDistanceLimit(maxDistance float64) float64 {
+	if maxDistance > 0 {
+		return maxDistance * maxDistance
+	}
+	return math.Inf(+1)
+}
+
+// visit the items in order of distance until fn returns false, skipping the ones farther than maxDistance.
+// distance of the bbox of a node has to be a lower bound of the distance of anything inside it
+func (t *rtree[C, E]) nearest(distance func(box []C) float64, maxDistance float64, fn func(leaf *rnode[C, E], i int, distance float64) bool) {
+	queue := make(knnQueue[knnEntry[C, E]], 0)
+	node := t.rootNode
+	for node != nil {
+		if node.isLeaf {
+			for i := range node.values {
+				d := distance(node.entryBox(i))
+				if d <= maxDistance {
+					heap.Push(&queue, knnElement[knnEntry[C, E]]{value: knnEntry[C, E]{node, i}, isItem: true, sqDistance: d})
+				}
+			}
+		}
+		for _, c := range node.children {
+			d := distance(c.bbox)
+			if d <= maxDistance {
+				heap.Push(&queue, knnElement[knnEntry[C, E]]{value: knnEntry[C, E]{node: c}, sqDistance: d})
+			}
+		}
+		// items at the top of the queue are closer than any node left to visit
+		for len(queue) != 0 && queue[0].isItem {
+			e := heap.Pop(&queue).(knnElement[knnEntry[C, E]])
+			if !fn(e.value.node, e.value.index, e.sqDistance) {
+				return
+			}
+		}
+		if len(queue) == 0 {
+			break
+		}
+		node = heap.Pop(&queue).(knnElement[knnEntry[C, E]]).value.node
+	}
+}
+
+// a node, or the item at index of a leaf
+type knnEntry[C Coordinate, E any] struct {
+	node  *rnode[C, E]
+	index int
+}
+
+// either a node or an item, identified by value
+type knnElement[T any] struct {
+	value      T
+	isItem     bool
+	sqDistance float64
+}
+
+// priority queue of nodes and items ordered by distance to the query point
+type knnQueue[T any] []knnElement[T]
+
+func (q knnQueue[T]) Len() int {
+	return len(q)
+}
+
+func (q knnQueue[T]) Less(i, j int) bool {
+	return q[i].sqDistance < q[j].sqDistance
+}
+
+func (q knnQueue[T]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *knnQueue[T]) Push(x interface{}) {
+	*q = append(*q, x.(knnElement[T]))
+}
+
+func (q *knnQueue[T]) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	*q = old[0 : n-1]
+	return e
+}
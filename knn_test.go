@@ -0,0 +1,77 @@
+package go_rbush
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestRBush_Knn(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	result := tree.Knn(36, 37, 3, 0, nil)
+	expected := [][4]float64{{35, 35, 35, 35}, {45, 45, 45, 45}, {25, 25, 25, 25}}
+	assertEqual(t, len(result), len(expected), "")
+	for i, p := range result {
+		assertEqual(t, p.(bboxes)[0], expected[i], "")
+	}
+}
+
+func TestRBush_KnnAllItems(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	result := tree.Knn(40, 40, 0, 0, nil)
+	assertEqual(t, len(result), len(data), "")
+	assertSortedByDistance(t, result, 40, 40)
+}
+
+func TestRBush_KnnMaxDistance(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	result := tree.Knn(40, 40, 0, 10, nil)
+	assertEqual(t, len(result), 2, "")
+	result = tree.Knn(200, 200, 5, 10, nil)
+	assertEqual(t, len(result), 0, "")
+}
+
+func TestRBush_KnnFilter(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	result := tree.Knn(40, 40, 1, 0, func(item Interface) bool {
+		x1, _, _, _ := item.GetBBoxAt(0)
+		return x1 < 30
+	})
+	assertEqual(t, len(result), 1, "")
+	assertEqual(t, result[0].(bboxes)[0], [4]float64{25, 50, 25, 50}, "")
+}
+
+func TestRBush_KnnEmptyTree(t *testing.T) {
+	assertEqual(t, len(New().Knn(0, 0, 3, 0, nil)), 0, "")
+}
+
+func TestRBush_KnnAgainstBruteForce(t *testing.T) {
+	data := getData(2000, 5)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data...))
+	for _, q := range getData(50, 0) {
+		result := tree.Knn(q[0], q[1], 10, 0, nil)
+		distances := make([]float64, len(data))
+		for i, d := range data {
+			distances[i] = BBox{d[0], d[1], d[2], d[3]}.sqDistanceToPoint(q[0], q[1])
+		}
+		sort.Float64s(distances)
+		assertEqual(t, len(result), 10, "")
+		for i, p := range result {
+			x1, y1, x2, y2 := p.GetBBoxAt(0)
+			assertEqual(t, BBox{x1, y1, x2, y2}.sqDistanceToPoint(q[0], q[1]), distances[i], "")
+		}
+	}
+}
+
+func assertSortedByDistance(t *testing.T, items []Interface, x, y float64) {
+	last := math.Inf(-1)
+	for _, p := range items {
+		x1, y1, x2, y2 := p.GetBBoxAt(0)
+		d := BBox{x1, y1, x2, y2}.sqDistanceToPoint(x, y)
+		if d < last {
+			t.Errorf("Items are not sorted by distance %v < %v", d, last)
+		}
+		last = d
+	}
+}
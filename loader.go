@@ -0,0 +1,272 @@
+package go_rbush
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Feature is a record read by ReadCSV or ReadGeoJSONSeq
+type Feature struct {
+	BBox       BBox
+	ID         string
+	Record     []string        // every field of the CSV row
+	Properties json.RawMessage // properties of the GeoJSON feature
+}
+
+// Features implements Interface, items returned by the index are Features of length 1
+type Features []Feature
+
+func (f Features) GetBBoxAt(i int) (x1, y1, x2, y2 float64) {
+	return f[i].BBox.MinX, f[i].BBox.MinY, f[i].BBox.MaxX, f[i].BBox.MaxY
+}
+
+func (f Features) Len() int {
+	return len(f)
+}
+
+func (f Features) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+}
+
+func (f Features) Slice(i, j int) Interface {
+	return f[i:j]
+}
+
+// CSVColumns names the columns of the header holding the id and the bbox of each row.
+// The zero value uses id, minX, minY, maxX and maxY. If MaxX and MaxY are empty rows are points at (MinX, MinY).
+// The id column is optional
+type CSVColumns struct {
+	ID, MinX, MinY, MaxX, MaxY string
+}
+
+// ReadCSV reads features from CSV with a header row. Errors are *LineError pointing at the offending line
+func ReadCSV(reader io.Reader, columns CSVColumns) (Features, error) {
+	if columns == (CSVColumns{}) {
+		columns = CSVColumns{ID: "id", MinX: "minX", MinY: "minY", MaxX: "maxX", MaxY: "maxY"}
+	}
+	if columns.MaxX == "" && columns.MaxY == "" {
+		columns.MaxX, columns.MaxY = columns.MinX, columns.MinY
+	}
+	r := csv.NewReader(reader)
+	header, err := r.Read()
+	if err == io.EOF {
+		return Features{}, nil
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[name] = i
+	}
+	var positions [4]int
+	for i, name := range []string{columns.MinX, columns.MinY, columns.MaxX, columns.MaxY} {
+		p, ok := index[name]
+		if !ok {
+			return nil, &LineError{Line: 1, Err: fmt.Errorf("missing column %q", name)}
+		}
+		positions[i] = p
+	}
+	idPosition, hasID := index[columns.ID]
+
+	features := Features{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return features, nil
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		line, _ := r.FieldPos(0)
+		var coordinates [4]float64
+		for i, p := range positions {
+			c, err := strconv.ParseFloat(record[p], 64)
+			if err != nil {
+				return nil, &LineError{Line: line, Err: fmt.Errorf("column %q: %w", header[p], err)}
+			}
+			coordinates[i] = c
+		}
+		f := Feature{BBox: BBox{coordinates[0], coordinates[1], coordinates[2], coordinates[3]}, Record: record}
+		if err := validateFeatureBBox(f.BBox); err != nil {
+			return nil, &LineError{Line: line, Err: err}
+		}
+		if hasID {
+			f.ID = record[idPosition]
+		}
+		features = append(features, f)
+	}
+}
+
+func csvError(err error) error {
+	var parseError *csv.ParseError
+	if errors.As(err, &parseError) {
+		return &LineError{Line: parseError.Line, Err: parseError.Err}
+	}
+	return err
+}
+
+// ReadGeoJSONSeq reads newline delimited GeoJSON features, one per line. Empty lines and the record separators
+// of GeoJSON text sequences (RFC 8142) are skipped. The bbox of a feature is its bbox member if present,
+// otherwise the extent of its geometry. Features with a null geometry and no bbox (unlocated features,
+// RFC 7946 section 3.2) are skipped. Errors are *LineError pointing at the offending line
+func ReadGeoJSONSeq(reader io.Reader) (Features, error) {
+	r := bufio.NewReader(reader)
+	features := Features{}
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		data = bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(data), []byte{0x1e}))
+		if len(data) != 0 {
+			f, parseErr := parseGeoJSONFeature(data)
+			if parseErr != nil && parseErr != errNoGeometry {
+				return nil, &LineError{Line: line, Err: parseErr}
+			}
+			if parseErr == nil {
+				features = append(features, f)
+			}
+		}
+		if err == io.EOF {
+			return features, nil
+		}
+	}
+}
+
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	ID         json.RawMessage  `json:"id"`
+	BBox       []float64        `json:"bbox"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties json.RawMessage  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometries  []geoJSONGeometry `json:"geometries"`
+}
+
+// errNoGeometry is returned by parseGeoJSONFeature for unlocated features, which have nothing to index
+var errNoGeometry = errors.New("feature without geometry")
+
+func parseGeoJSONFeature(data []byte) (Feature, error) {
+	var gf geoJSONFeature
+	if err := json.Unmarshal(data, &gf); err != nil {
+		return Feature{}, err
+	}
+	if gf.Type != "Feature" {
+		return Feature{}, fmt.Errorf("expected a Feature, got type %q", gf.Type)
+	}
+	f := Feature{Properties: gf.Properties}
+	if len(gf.ID) != 0 {
+		// ids are strings or numbers
+		if err := json.Unmarshal(gf.ID, &f.ID); err != nil {
+			f.ID = string(gf.ID)
+		}
+	}
+	switch {
+	case len(gf.BBox) != 0:
+		if len(gf.BBox)%2 != 0 || len(gf.BBox) < 4 {
+			return Feature{}, fmt.Errorf("bbox with %v values", len(gf.BBox))
+		}
+		dims := len(gf.BBox) / 2
+		f.BBox = BBox{gf.BBox[0], gf.BBox[1], gf.BBox[dims], gf.BBox[dims+1]}
+	case gf.Geometry != nil:
+		f.BBox = BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		if err := gf.Geometry.extend(&f.BBox); err != nil {
+			return Feature{}, err
+		}
+		if f.BBox.MinX > f.BBox.MaxX {
+			return Feature{}, errors.New("geometry without positions")
+		}
+	default:
+		return Feature{}, errNoGeometry
+	}
+	return f, validateFeatureBBox(f.BBox)
+}
+
+// extend the bbox with every position of the geometry
+func (g *geoJSONGeometry) extend(b *BBox) error {
+	switch g.Type {
+	case "GeometryCollection":
+		for i := range g.Geometries {
+			if err := g.Geometries[i].extend(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Point", "MultiPoint", "LineString", "MultiLineString", "Polygon", "MultiPolygon":
+		var coordinates interface{}
+		if err := json.Unmarshal(g.Coordinates, &coordinates); err != nil {
+			return fmt.Errorf("%v coordinates: %w", g.Type, err)
+		}
+		return extendWithPositions(b, coordinates)
+	default:
+		return fmt.Errorf("unknown geometry type %q", g.Type)
+	}
+}
+
+// coordinates are nested arrays of positions, a position is an array of numbers
+func extendWithPositions(b *BBox, coordinates interface{}) error {
+	values, ok := coordinates.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected an array of coordinates, got %v", coordinates)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if _, isNumber := values[0].(float64); !isNumber {
+		for _, v := range values {
+			if err := extendWithPositions(b, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(values) < 2 {
+		return fmt.Errorf("position with %v values", len(values))
+	}
+	x, okX := values[0].(float64)
+	y, okY := values[1].(float64)
+	if !okX || !okY {
+		return fmt.Errorf("invalid position %v", values)
+	}
+	*b = b.extend(BBox{x, y, x, y})
+	return nil
+}
+
+func validateFeatureBBox(b BBox) error {
+	if math.IsNaN(b.MinX) || math.IsNaN(b.MinY) || math.IsNaN(b.MaxX) || math.IsNaN(b.MaxY) || b.MinX > b.MaxX || b.MinY > b.MaxY {
+		return fmt.Errorf("invalid bbox %v", b)
+	}
+	return nil
+}
+
+// LoadCSV reads the features with ReadCSV and loads them. The returned collection is the one referenced by the index.
+// The index is not modified if reading fails
+func (r *RBush) LoadCSV(reader io.Reader, columns CSVColumns) (Features, error) {
+	features, err := ReadCSV(reader, columns)
+	if err != nil {
+		return nil, err
+	}
+	return features, r.LoadContext(context.Background(), features)
+}
+
+// LoadGeoJSONSeq reads the features with ReadGeoJSONSeq and loads them, see LoadCSV
+func (r *RBush) LoadGeoJSONSeq(reader io.Reader) (Features, error) {
+	features, err := ReadGeoJSONSeq(reader)
+	if err != nil {
+		return nil, err
+	}
+	return features, r.LoadContext(context.Background(), features)
+}
@@ -0,0 +1,168 @@
+package go_rbush
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRBush_LoadCSV(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("id,minX,minY,maxX,maxY,name\n")
+	data := getData(1000, 1)
+	for i, d := range data {
+		fmt.Fprintf(&csv, "%v,%v,%v,%v,%v,\"item, %v\"\n", i, d[0], d[1], d[2], d[3], i)
+	}
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9})
+	features, err := tree.LoadCSV(strings.NewReader(csv.String()), CSVColumns{})
+	assertNoError(t, err)
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(features), len(data), "")
+	assertEqual(t, len(tree.All()), len(data), "")
+	for _, f := range features {
+		i, err := strconv.Atoi(f.ID)
+		assertNoError(t, err)
+		assertEqual(t, f.BBox, BBox{data[i][0], data[i][1], data[i][2], data[i][3]}, "")
+		assertEqual(t, f.Record[5], "item, "+f.ID, "")
+	}
+	for _, q := range getData(50, 10) {
+		b := BBox{q[0], q[1], q[2], q[3]}
+		expected := 0
+		for _, d := range data {
+			if b.intersects(BBox{d[0], d[1], d[2], d[3]}) {
+				expected++
+			}
+		}
+		assertEqual(t, len(tree.Search(b)), expected, "")
+	}
+}
+
+func TestReadCSV_Points(t *testing.T) {
+	features, err := ReadCSV(strings.NewReader("lon,lat\n1.5,2\n-3,4.25\n"), CSVColumns{MinX: "lon", MinY: "lat"})
+	assertNoError(t, err)
+	assertEqual(t, len(features), 2, "")
+	assertEqual(t, features[1].BBox, BBox{-3, 4.25, -3, 4.25}, "")
+	assertEqual(t, features[1].ID, "", "")
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	cases := []struct {
+		input string
+		line  int
+		msg   string
+	}{
+		{"id,minX,minY,maxX\n1,0,0,1\n", 1, `missing column "maxY"`},
+		{"id,minX,minY,maxX,maxY\n1,0,0,1,1\n2,0,zero,1,1\n", 3, `column "minY"`},
+		{"id,minX,minY,maxX,maxY\n1,0,0,1,1\n\n2,5,0,1,1\n", 4, "invalid bbox"},
+		{"id,minX,minY,maxX,maxY\n1,0,0,1,1\n2,0,0,1\n", 3, "wrong number of fields"},
+		{"id,minX,minY,maxX,maxY\n1,0,0,1,1\n\"2,0,0,1,1\n", 3, "quote"},
+	}
+	for _, c := range cases {
+		tree := New()
+		_, err := tree.LoadCSV(strings.NewReader(c.input), CSVColumns{})
+		var lineError *LineError
+		assertEqual(t, errors.As(err, &lineError), true, c.input)
+		assertEqual(t, lineError.Line, c.line, c.input)
+		assertEqual(t, strings.Contains(err.Error(), c.msg), true, err.Error())
+		assertEqual(t, len(tree.All()), 0, "Index should not be modified")
+	}
+}
+
+const geoJSONSeq = `{"type":"Feature","id":"a","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"name":"point"}}
+
+{"type":"Feature","id":7,"geometry":{"type":"LineString","coordinates":[[0,0,10],[5,-1,10],[3,4,10]]},"properties":null}
+` + "\x1e" + `{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[10,10],[20,10],[20,30],[10,10]]],[[[-5,0],[0,0],[0,1],[-5,0]]]]}}
+{"type":"Feature","bbox":[100,100,0,110,120,5],"geometry":{"type":"Point","coordinates":[105,105]}}
+{"type":"Feature","geometry":{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[50,50]},{"type":"MultiPoint","coordinates":[[40,60],[45,45]]}]}}`
+
+func TestRBush_LoadGeoJSONSeq(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4})
+	features, err := tree.LoadGeoJSONSeq(strings.NewReader(geoJSONSeq))
+	assertNoError(t, err)
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), 5, "")
+	bboxes := map[string]BBox{}
+	for _, f := range features {
+		bboxes[f.ID] = f.BBox
+	}
+	assertEqual(t, bboxes["a"], BBox{1, 2, 1, 2}, "")
+	assertEqual(t, bboxes["7"], BBox{0, -1, 5, 4}, "")
+	assertEqual(t, len(bboxes), 3, "Features without id share the empty id")
+
+	point := tree.SearchItems(BBox{1, 2, 1, 2})
+	assertEqual(t, len(point), 3, "Point, LineString and MultiPolygon")
+	for _, p := range point {
+		if f := p.(Features)[0]; f.ID == "a" {
+			assertEqual(t, string(f.Properties), `{"name":"point"}`, "")
+		}
+	}
+	found := tree.SearchItems(BBox{104, 104, 106, 106})
+	assertEqual(t, len(found), 1, "")
+	assertEqual(t, found[0].(Features)[0].BBox, BBox{100, 100, 110, 120}, "bbox member is used when present")
+	collection := tree.SearchItems(BBox{41, 46, 44, 49})
+	assertEqual(t, len(collection), 1, "")
+	assertEqual(t, collection[0].(Features)[0].BBox, BBox{40, 45, 50, 60}, "")
+	multiPolygon := tree.SearchItems(BBox{-4, 0.5, -4, 0.5})
+	assertEqual(t, len(multiPolygon), 1, "")
+	assertEqual(t, multiPolygon[0].(Features)[0].BBox, BBox{-5, 0, 20, 30}, "")
+}
+
+func TestSyncRBush_Loaders(t *testing.T) {
+	tree := NewSync()
+	features, err := tree.LoadGeoJSONSeq(strings.NewReader(geoJSONSeq))
+	assertNoError(t, err)
+	assertEqual(t, len(features), 5, "")
+	assertEqual(t, len(tree.SearchItems(BBox{1, 2, 1, 2})), 3, "")
+	features, err = tree.LoadCSV(strings.NewReader("id,minX,minY,maxX,maxY\n1,0,0,1,1\n"), CSVColumns{})
+	assertNoError(t, err)
+	assertEqual(t, len(features), 1, "")
+	assertEqual(t, len(tree.All()), 6, "")
+	_, err = tree.LoadCSV(strings.NewReader("id,minX\n1,0\n"), CSVColumns{})
+	assertEqual(t, err != nil, true, "")
+	assertEqual(t, len(tree.All()), 6, "Index should not be modified")
+}
+
+func TestReadGeoJSONSeq_Errors(t *testing.T) {
+	valid := `{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}` + "\n"
+	cases := []struct {
+		line string
+		msg  string
+	}{
+		{`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}`, "unexpected end"},
+		{`{"type":"FeatureCollection","features":[]}`, "expected a Feature"},
+		{`{"type":"Feature","geometry":{"type":"Circle","coordinates":[1,2]}}`, "unknown geometry"},
+		{`{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1,2],[3]]}}`, "position with 1 values"},
+		{`{"type":"Feature","geometry":{"type":"MultiPoint","coordinates":[]}}`, "without positions"},
+		{`{"type":"Feature","bbox":[1,2,3],"geometry":{"type":"Point","coordinates":[1,2]}}`, "bbox with 3 values"},
+		{`{"type":"Feature","bbox":[3,2,1,4],"geometry":{"type":"Point","coordinates":[1,2]}}`, "invalid bbox"},
+	}
+	for _, c := range cases {
+		_, err := ReadGeoJSONSeq(strings.NewReader(valid + "\n" + valid + c.line + "\n" + valid))
+		var lineError *LineError
+		assertEqual(t, errors.As(err, &lineError), true, c.line)
+		assertEqual(t, lineError.Line, 4, c.line)
+		assertEqual(t, strings.Contains(err.Error(), c.msg), true, err.Error())
+	}
+}
+
+func TestReadGeoJSONSeq_NullGeometry(t *testing.T) {
+	features, err := ReadGeoJSONSeq(strings.NewReader(`{"type":"Feature","id":"a","geometry":null}
+{"type":"Feature","id":"b","geometry":{"type":"Point","coordinates":[1,2]}}
+{"type":"Feature","id":"c","bbox":[0,0,1,1],"geometry":null}
+{"type":"Feature","id":"d"}`))
+	assertNoError(t, err)
+	assertEqual(t, len(features), 2, "Features without geometry nor bbox should be skipped")
+	assertEqual(t, features[0].ID, "b", "")
+	assertEqual(t, features[1].BBox, BBox{0, 0, 1, 1}, "")
+}
+
+func TestReadGeoJSONSeq_Empty(t *testing.T) {
+	features, err := ReadGeoJSONSeq(strings.NewReader("\n\n"))
+	assertNoError(t, err)
+	assertEqual(t, len(features), 0, "")
+	features, err = ReadCSV(strings.NewReader(""), CSVColumns{})
+	assertNoError(t, err)
+	assertEqual(t, len(features), 0, "")
+}
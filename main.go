@@ -3,25 +3,50 @@ package go_rbush
 
 // Interface abstract the required properties for an slice of points
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"math"
 	"runtime"
+	"sort"
+	"sync"
 )
 
 const (
+	// Deprecated: the minimum number of entries of a node is configured with Options.MIN_ENTRIES
 	MIN_ENTRIES         = 4
-	MAX_HEIGHT_TO_SPLIT = 3 // When creating the index we'll split the task into a new goroutine until we reach this height
+	MAX_HEIGHT_TO_SPLIT = 3 // When creating the index we'll hand subtrees to other workers until we reach this height
 )
 
 type Interface interface {
 	GetBBoxAt(i int) (x1, y1, x2, y2 float64) // Retrieve point at position i
 	Len() int                                 // Number of elements
-	Swap(i, j int)                            // Swap elements with indexes i and j
+	Swap(i, j int)                            // Swap elements with indexes i and j. Not used anymore, Load copies the bboxes and sorts the copy
 	Slice(i, j int) Interface                 //Slice the interface between two indices
 }
 
 type Options struct {
 	MAX_ENTRIES int
+	MIN_ENTRIES int // Minimum number of entries of a node on split and removal, 40% of MAX_ENTRIES if 0
+	MAX_WORKERS int // Maximum number of goroutines building the tree on Load, GOMAXPROCS if 0. With 1 the tree is built in the calling goroutine
+}
+
+// ErrInvalidOptions is wrapped by the errors returned by Options.Validate and by NewOfWithValidOptions
+var ErrInvalidOptions = errors.New("rbush: invalid options")
+
+// Validate checks that nodes can be split with the given options.
+// MAX_ENTRIES has to be at least 4 and MIN_ENTRIES, if set, between 2 and half of MAX_ENTRIES
+func (o Options) Validate() error {
+	if o.MAX_ENTRIES < 4 {
+		return fmt.Errorf("%w: MAX_ENTRIES is %v, it should be at least 4", ErrInvalidOptions, o.MAX_ENTRIES)
+	}
+	if o.MIN_ENTRIES != 0 && (o.MIN_ENTRIES < 2 || o.MIN_ENTRIES > o.MAX_ENTRIES/2) {
+		return fmt.Errorf("%w: MIN_ENTRIES is %v, it should be between 2 and %v", ErrInvalidOptions, o.MIN_ENTRIES, o.MAX_ENTRIES/2)
+	}
+	if o.MAX_WORKERS < 0 {
+		return fmt.Errorf("%w: MAX_WORKERS is %v, it should not be negative", ErrInvalidOptions, o.MAX_WORKERS)
+	}
+	return nil
 }
 
 // Create an RBush index from an array of points
@@ -32,342 +57,815 @@ func New() *RBush {
 	return NewWithOptions(defaultOptions)
 }
 
-func NewWithOptions(options Options) *RBush {
-	r := &RBush{
-		options: options,
+// NewWithValidOptions creates an index after validating the options, see Options.Validate
+func NewWithValidOptions(options Options) (*RBush, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
 	}
-	r.initRootNode()
-	return r
+	return NewWithOptions(options), nil
 }
 
+// NewWithOptions creates an index without validating the options
+func NewWithOptions(options Options) *RBush {
+	return &RBush{rtree: newRTree[float64, item](2, options)}
+}
+
+// RBush is a 2D index of the items of Interface collections. It is the generic rtree with float64 coordinates
+// storing for each item its position in the collection
 type RBush struct {
-	options  Options
-	rootNode *Node
+	rtree[float64, item]
 }
 
+// Node is an item returned by Search or All, with its bbox. The nodes of the tree itself are not exposed
 type Node struct {
-	children   []*Node
-	height     int
-	isLeaf     bool
-	points     Interface
-	parentNode *Node
-	BBox       BBox
+	points Interface
+	BBox   BBox
 }
 
-func (r *RBush) Search(b BBox) []*Node {
-	// TODO remove
-	_ = runtime.GOOS
-	node := r.rootNode
-	result := make([]*Node, 0)
-	if !node.BBox.intersects(b) {
-		return result
-	}
-	nodesToSearch := make([]*Node, 0, 1)
-	nodesToSearch = append(nodesToSearch, node)
-	for len(nodesToSearch) != 0 {
-		// pop first item
-		node, nodesToSearch = nodesToSearch[0], nodesToSearch[1:]
-		for _, c := range node.children {
-			if b.intersects(c.BBox) {
-				if node.isLeaf {
-					// child is basically a point
-					result = append(result, c)
-				} else if b.contains(c.BBox) {
-					result = append(result, c.flattenDownwards()...)
-				} else {
-					nodesToSearch = append(nodesToSearch, c)
-				}
-			}
+// An item is the element at position index of points, which for loaded items is the whole loaded collection
+type item struct {
+	points Interface
+	index  int
+}
+
+// Interface of length 1 holding the item
+func (it item) value() Interface {
+	if it.points.Len() == 1 {
+		return it.points
+	}
+	return it.points.Slice(it.index, it.index+1)
+}
+
+// nodes of RBush, named for the functions that only deal with them such as the JSON and binary encodings
+type rbushNode = rnode[float64, item]
+
+// bboxes and items of the collection, the bboxes of all of them are stored in a single allocation
+func newItems(points Interface) ([]float64, []item) {
+	n := points.Len()
+	coordinates := make([]float64, 4*n)
+	items := make([]item, n)
+	for i := range items {
+		c := coordinates[4*i : 4*i+4]
+		c[0], c[1], c[2], c[3] = points.GetBBoxAt(i)
+		items[i] = item{points: points, index: i}
+	}
+	return coordinates, items
+}
+
+// item nodes are only created to be returned to the user, all of them in a single allocation
+func itemsToNodes(refs []itemRef) []*Node {
+	nodes := make([]Node, len(refs))
+	result := make([]*Node, len(refs))
+	for i, ref := range refs {
+		leaf := ref.leaf.(*rbushNode)
+		nodes[i] = Node{
+			BBox:   flatBBox(leaf.entryBox(ref.index)),
+			points: leaf.values[ref.index].value(),
 		}
+		result[i] = &nodes[i]
 	}
 	return result
 }
 
+func (r *RBush) Search(b BBox) []*Node {
+	buf := getItemBuffer()
+	defer putItemBuffer(buf)
+	q := b.flat()
+	r.collect(q[:], buf)
+	return itemsToNodes(buf.refs)
+}
+
+// SearchFunc calls fn for every item intersecting the bbox, as it was given on Load or InsertElement,
+// until fn returns false. Each item is an Interface of length 1. fn must not modify the index
+func (r *RBush) SearchFunc(b BBox, fn func(Interface) bool) {
+	q := b.flat()
+	r.search(q[:], func(leaf *rbushNode, i int) bool {
+		return fn(leaf.values[i].value())
+	})
+}
+
+// SearchItems returns the items intersecting the bbox, as they were given on Load or InsertElement.
+// Each item is an Interface of length 1
+func (r *RBush) SearchItems(b BBox) []Interface {
+	buf := getItemBuffer()
+	defer putItemBuffer(buf)
+	q := b.flat()
+	r.collect(q[:], buf)
+	return r.interfaces(buf.refs)
+}
+
+func (r *RBush) interfaces(refs []itemRef) []Interface {
+	result := make([]Interface, len(refs))
+	for i, ref := range refs {
+		leaf := ref.leaf.(*rbushNode)
+		result[i] = leaf.values[ref.index].value()
+	}
+	return result
+}
+
+// Collides tells whether some item intersects the bbox. It is equivalent to len(Search(b)) > 0
 func (r *RBush) Collides(b BBox) bool {
-	node := r.rootNode
-	if !node.BBox.intersects(b) {
-		return false
+	q := b.flat()
+	return r.collides(q[:])
+}
+
+// CollidesAll tells for each bbox whether some item intersects it, as Collides would.
+// All the bboxes are checked in a single walk of the tree
+func (r *RBush) CollidesAll(boxes []BBox) []bool {
+	return r.collidesAll(flatBBoxes(boxes))
+}
+
+// Points returns the item stored in the node
+func (n *Node) Points() Interface {
+	return n.points
+}
+
+func (r *RBush) Load(points Interface) *RBush {
+	if err := r.LoadContext(context.Background(), points); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *RBush) LoadSortedArray(points Interface) *RBush {
+	if err := r.LoadSortedArrayContext(context.Background(), points); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadContext is like Load but stops building the tree when the context is done, returning the context error.
+// In that case the index is left unchanged. A *TreeError is returned if the index is found to be inconsistent
+func (r *RBush) LoadContext(ctx context.Context, points Interface) error {
+	coordinates, items := newItems(points)
+	return r.load(ctx, coordinates, items, false)
+}
+
+// LoadSortedArrayContext is like LoadSortedArray but can be cancelled, see LoadContext
+func (r *RBush) LoadSortedArrayContext(ctx context.Context, points Interface) error {
+	coordinates, items := newItems(points)
+	return r.load(ctx, coordinates, items, true)
+}
+
+// InsertElement adds a single item to the index. p is expected to have length 1.
+// A *TreeError is returned if the index is found to be inconsistent
+func (r *RBush) InsertElement(p Interface) error {
+	b := interfaceBBox(p).flat()
+	return r.insertItem(b[:], item{points: p})
+}
+
+// Clear removes all items from the index
+func (r *RBush) Clear() *RBush {
+	if err := r.clear(); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// All returns all the item nodes in the index
+func (r *RBush) All() []*Node {
+	buf := getItemBuffer()
+	defer putItemBuffer(buf)
+	r.collect(nil, buf)
+	return itemsToNodes(buf.refs)
+}
+
+// Remove removes the item from the index. See RemoveElement to know if the item was found
+func (r *RBush) Remove(p ToBeRemoved) *RBush {
+	if _, err := r.RemoveElement(p); err != nil {
+		panic(err)
 	}
-	nodesToSearch := make([]*Node, 0, 10)
-	nodesToSearch = append(nodesToSearch, node)
-	for len(nodesToSearch) != 0 {
-		// pop first item
-		node, nodesToSearch = nodesToSearch[0], nodesToSearch[1:]
-		for _, c := range node.children {
-			// TODO leaf
-			if c.BBox.intersects(b) {
-				if node.isLeaf || b.contains(c.BBox) {
-					return true
+	return r
+}
+
+// RemoveElement removes the item from the index and reports whether it was found.
+// Nodes left with less than the minimum number of entries are dissolved and their entries reinserted.
+// A *TreeError is returned if the index is found to be inconsistent
+func (r *RBush) RemoveElement(p ToBeRemoved) (bool, error) {
+	b, match := toBeRemovedItem(p)
+	return r.remove(b[:], match)
+}
+
+type ToBeRemoved interface {
+	GetBBox () (x1, y1, x2, y2 float64)
+	IsContained (points Interface) bool
+}
+
+// bbox of the item to remove and whether a stored item is the one to remove
+func toBeRemovedItem(p ToBeRemoved) ([4]float64, func(item) bool) {
+	x1, y1, x2, y2 := p.GetBBox()
+	return [4]float64{x1, y1, x2, y2}, func(it item) bool {
+		return p.IsContained(it.value())
+	}
+}
+
+// ToBBox returns the bbox of all the items in the index
+func (r *RBush) ToBBox() BBox {
+	return flatBBox(r.rootNode.bbox)
+}
+
+// rtree is the index shared by RBush, RBushOf and Tree: an rbush of dims dimensions with coordinates of type C
+// whose items are values of type E.
+// Nodes don't keep a reference to their parent so they can be shared between trees.
+// Modifications walk down from the root keeping track of the path
+type rtree[C Coordinate, E any] struct {
+	dims     int
+	options  Options
+	rootNode *rnode[C, E]
+	cow      *copyOnWrite // nodes owned by this tree, the rest are shared with snapshots and are copied before being modified
+	readOnly bool         // snapshots cannot be modified
+}
+
+// Bboxes are stored flat, see emptyBox. Unlike original rbush items are not nodes, leaves store them contiguously:
+// the bbox of the i-th item is the i-th run of 2*dims coordinates and its value is values[i]
+type rnode[C Coordinate, E any] struct {
+	children    []*rnode[C, E]
+	coordinates []C // bboxes of the items of leaves
+	values      []E // items of leaves
+	bbox        []C
+	height      int
+	isLeaf      bool
+	cow         *copyOnWrite
+}
+
+// Identifies the tree that can modify a node in place. It must not be zero sized so that every instance is different
+type copyOnWrite struct {
+	_ byte
+}
+
+func newRTree[C Coordinate, E any](dims int, options Options) rtree[C, E] {
+	t := rtree[C, E]{
+		dims:    dims,
+		options: options,
+		cow:     &copyOnWrite{},
+	}
+	t.initRootNode()
+	return t
+}
+
+func (t *rtree[C, E]) initRootNode() {
+	t.rootNode = &rnode[C, E]{
+		values: []E{},
+		bbox:   emptyBox[C](t.dims),
+		isLeaf: true,
+		height: 1,
+		cow:    t.cow,
+	}
+}
+
+func (t *rtree[C, E]) clear() error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	t.initRootNode()
+	return nil
+}
+
+// visit the items intersecting the box, returns false if fn stopped the search
+func (t *rtree[C, E]) search(box []C, fn func(leaf *rnode[C, E], i int) bool) bool {
+	if !boxIntersects(t.rootNode.bbox, box) {
+		return true
+	}
+	return t.rootNode.walk(box, boxContains(box, t.rootNode.bbox), fn)
+}
+
+// append to the buffer the items intersecting the box, every item if box is nil
+func (t *rtree[C, E]) collect(box []C, buf *itemBuffer) {
+	fn := func(leaf *rnode[C, E], i int) bool {
+		buf.refs = append(buf.refs, itemRef{leaf: leaf, index: i})
+		return true
+	}
+	if box == nil {
+		t.rootNode.walk(nil, true, fn)
+		return
+	}
+	t.search(box, fn)
+}
+
+func (t *rtree[C, E]) collides(box []C) bool {
+	return boxIntersects(t.rootNode.bbox, box) && t.rootNode.collides(box)
+}
+
+// boxes are the query boxes one after the other, see CollidesAll
+func (t *rtree[C, E]) collidesAll(boxes []C) []bool {
+	stride := 2 * t.dims
+	batch := collisionBatch[C, E]{
+		boxes:  boxes,
+		stride: stride,
+		result: make([]bool, len(boxes)/stride),
+	}
+	pending := make([]int, 0, len(batch.result))
+	for i := range batch.result {
+		if boxIntersects(batch.box(i), t.rootNode.bbox) {
+			pending = append(pending, i)
+		}
+	}
+	batch.visit(t.rootNode, pending)
+	return batch.result
+}
+
+type collisionBatch[C Coordinate, E any] struct {
+	boxes  []C
+	stride int
+	result []bool
+	buf    []int // pending boxes of each level of the walk, stacked one after the other
+}
+
+func (cb *collisionBatch[C, E]) box(q int) []C {
+	return cb.boxes[q*cb.stride : (q+1)*cb.stride]
+}
+
+// pending are the indexes of the boxes that intersect the node and have not collided yet.
+// The walk owns pending, which is modified in place
+func (cb *collisionBatch[C, E]) visit(n *rnode[C, E], pending []int) {
+	if n.isLeaf {
+		for _, q := range pending {
+			for i := range n.values {
+				if boxIntersects(cb.box(q), n.entryBox(i)) {
+					cb.result[q] = true
+					break
 				}
-				nodesToSearch = append(nodesToSearch, c)
 			}
 		}
+		return
+	}
+	for _, c := range n.children {
+		start := len(cb.buf)
+		for _, q := range pending {
+			if !boxIntersects(cb.box(q), c.bbox) {
+				continue
+			}
+			if boxContains(cb.box(q), c.bbox) {
+				cb.result[q] = true
+				continue
+			}
+			cb.buf = append(cb.buf, q)
+		}
+		if len(cb.buf) > start {
+			cb.visit(c, cb.buf[start:])
+			cb.buf = cb.buf[:start]
+		}
+		pending = cb.unresolved(pending)
 	}
-	return false
 }
 
-// Returns all end points inside node
-func (n *Node) flattenDownwards() []*Node {
-	var node *Node
-	// runtime.Breakpoint()
-	result := make([]*Node, 0, len(n.children))
-	nodesToSearch := []*Node{n}
-	for len(nodesToSearch) != 0 {
-		node, nodesToSearch = nodesToSearch[0], nodesToSearch[1:]
-		if node.isLeaf {
-			result = append(result, node.children...)
-		} else {
-			nodesToSearch = append(nodesToSearch, node.children...)
+// remove from pending, in place, the boxes that already collided
+func (cb *collisionBatch[C, E]) unresolved(pending []int) []int {
+	result := pending[:0]
+	for _, q := range pending {
+		if !cb.result[q] {
+			result = append(result, q)
 		}
 	}
 	return result
 }
 
-func (r *RBush) Load(points Interface) *RBush {
-	return r.load(points, false)
-}
-
-func (r *RBush) LoadSortedArray(points Interface) *RBush {
-	return r.load(points, true)
+// Returns all end points inside node
+func (n *rnode[C, E]) flattenDownwards() []E {
+	result := make([]E, 0, n.numEntries())
+	n.walk(nil, true, func(leaf *rnode[C, E], i int) bool {
+		result = append(result, leaf.values[i])
+		return true
+	})
+	return result
 }
 
-func (r *RBush) load (points Interface, isSorted bool) *RBush {
-	if points.Len() == 0 {
-		return r
+// bulk insert the items, the bbox of the i-th item is the i-th run of 2*dims coordinates.
+// Both slices are owned by the tree from now on, leaves keep parts of them
+func (t *rtree[C, E]) load(ctx context.Context, coordinates []C, values []E, isSorted bool) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
 	}
 
-	if points.Len() < MIN_ENTRIES {
-		for i := 0; i < points.Len(); i++ {
-			r.InsertElement(points.Slice(i, i+1))
+	// not worth building a tree
+	if len(values) < t.minEntries() {
+		stride := 2 * t.dims
+		for i, v := range values {
+			if err := t.insert(coordinates[i*stride:(i+1)*stride], v); err != nil {
+				return err
+			}
 		}
-		return r
-	}
-	// TODO points.Len < MIN_ENTRIEs
-	node := r.build(points, isSorted)
-	if len(r.rootNode.children) == 0 {
-		r.rootNode = node
-	} else if r.rootNode.height == node.height {
-		r.splitRoot(node)
+		return nil
+	}
+	node, err := t.build(ctx, coordinates, values, isSorted)
+	if err != nil {
+		return err
+	}
+	if t.rootNode.numEntries() == 0 {
+		t.rootNode = node
+	} else if t.rootNode.height == node.height {
+		t.splitRoot(node)
 	} else {
-		if r.rootNode.height < node.height {
+		if t.rootNode.height < node.height {
 			// swap nodes and insert smaller one
-			tmpNode := r.rootNode
-			r.rootNode = node
+			tmpNode := t.rootNode
+			t.rootNode = node
 			node = tmpNode
 		}
 		// insert small tree into big tree
-		r.insertNode(node)
+		if err := t.insertNode(node); err != nil {
+			return err
+		}
 	}
 
-	return r
+	return nil
 }
 
-// points is assumed to be ordered
-func (r *RBush) build(points Interface, isSorted bool) *Node {
+// Subtrees are built by a pool of workers. Workers queue children for other workers while the queue has room
+// and build them themselves otherwise, so the number of goroutines is bounded
+type builder[C Coordinate, E any] struct {
+	t           *rtree[C, E]
+	ctx         context.Context
+	coordinates []C // loaded items, sorted in place. Workers sort disjoint ranges
+	values      []E
+	tasks       chan buildTask[C, E]
+	pending     sync.WaitGroup
+}
 
-	confirmCh := make(chan int, 1)
+// n covers length items of the loaded ones from offset
+type buildTask[C Coordinate, E any] struct {
+	n              *rnode[C, E]
+	offset, length int
+	isSorted       bool
+}
 
-	rootNode := &Node{
-		height: int(math.Ceil(math.Log(float64(points.Len())) / math.Log(float64(r.options.MAX_ENTRIES)))),
-		points: points}
-	remainingNodes := 1
+// coordinates are sorted along the first dimension if isSorted
+func (t *rtree[C, E]) build(ctx context.Context, coordinates []C, values []E, isSorted bool) (*rnode[C, E], error) {
+	rootNode := &rnode[C, E]{
+		height: int(math.Ceil(math.Log(float64(len(values))) / math.Log(float64(t.options.MAX_ENTRIES)))),
+		bbox:   make([]C, 2*t.dims),
+		cow:    t.cow}
 
-	go r.buildNodeDownwards(rootNode, confirmCh, true, isSorted)
-	for remainingNodes > 0 {
-		i := <-confirmCh
-		remainingNodes += i
+	b := &builder[C, E]{t: t, ctx: ctx, coordinates: coordinates, values: values}
+	root := buildTask[C, E]{n: rootNode, length: len(values), isSorted: isSorted}
+	workers := t.options.MAX_WORKERS
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// subtrees up to MAX_HEIGHT_TO_SPLIT are built inline, so small trees are not worth starting the workers.
+	// Options are not validated by NewWithOptions, negative workers are sequential as well
+	if workers <= 1 || rootNode.height <= MAX_HEIGHT_TO_SPLIT {
+		b.buildNodeDownwards(root)
+	} else {
+		b.tasks = make(chan buildTask[C, E], workers)
+		for i := 0; i < workers; i++ {
+			go b.work()
+		}
+		b.pending.Add(1)
+		b.tasks <- root
+		b.pending.Wait()
+		close(b.tasks)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	close(confirmCh)
 	rootNode.computeBBoxDownwards()
-	return rootNode
+	return rootNode, nil
 }
 
-func (r *RBush) buildNodeDownwards(n *Node, confirmCh chan int, isCalledAsync, isSorted bool) {
-	if isCalledAsync {
-		defer func() {
-			confirmCh <- -1
-		}()
+func (b *builder[C, E]) work() {
+	for t := range b.tasks {
+		b.buildNodeDownwards(t)
+		b.pending.Done()
 	}
+}
 
-	N := n.points.Len()
+func (b *builder[C, E]) buildNodeDownwards(task buildTask[C, E]) {
+	if b.ctx.Err() != nil {
+		return
+	}
+	t := b.t
+	n, N := task.n, task.length
 	// target number of root entries to maximize storage utilization
 	var M float64
-	if N <= r.options.MAX_ENTRIES { // Leaf node
-		n.setLeafNode(n.points)
+	if N <= t.options.MAX_ENTRIES { // Leaf node
+		b.setLeafNode(n, task.offset, N)
 		return
 	}
 
-	M = math.Ceil(float64(N) / float64(math.Pow(float64(r.options.MAX_ENTRIES), float64(n.height-1))))
+	M = math.Ceil(float64(N) / float64(math.Pow(float64(t.options.MAX_ENTRIES), float64(n.height-1))))
 
-	N2 := int(math.Ceil(float64(N) / M))
-	N1 := N2 * int(math.Ceil(math.Sqrt(M)))
-
-	// parent node might already be sorted. In that case we avoid double computation
-	if (n.parentNode != nil || !isSorted) {
-		sortX := xSorter{n: n, start: 0, end: n.points.Len(), bucketSize:  N1}
-		sortX.Sort()
+	// OMT generalized to N dimensions: items are split into slabs along the first dimension, each slab into slabs
+	// along the second one and so on. Slabs of the last dimension become the children
+	childSize := int(math.Ceil(float64(N) / M))
+	slabs := int(math.Ceil(math.Pow(M, 1/float64(t.dims))))
+	children := make([]buildTask[C, E], 0, int(M))
+	b.buildSlabs(n, task.offset, N, 0, childSize, slabs, task.isSorted, &children)
+	// bboxes of the children in a single allocation, capped so they never write on their neighbours
+	stride := 2 * t.dims
+	bboxes := make([]C, stride*len(n.children))
+	for i, c := range n.children {
+		c.bbox = bboxes[i*stride : (i+1)*stride : (i+1)*stride]
 	}
-	// runtime.Breakpoint()
-	for i := 0; i < n.points.Len(); i += N1 {
-		right2 := minInt(i+N1, n.points.Len())
-		sortY := ySorter{n: n, start: i, end: right2, bucketSize: N2}
-		sortY.Sort()
-		for j := i; j < right2; j += N2 {
-			right3 := minInt(j+N2, right2)
-			child := Node{
-				points:     n.points.Slice(j, right3),
-				height:     n.height - 1,
-				parentNode: n,
-			}
-			n.children = append(n.children, &child)
-			// remove reference to interface, we only need it for points
 
+	// compute children
+	for _, c := range children {
+		// Only hand big subtrees to other workers. we don't want a worker to sort 4 points
+		if b.tasks == nil || n.height <= MAX_HEIGHT_TO_SPLIT {
+			b.buildNodeDownwards(c)
+			continue
+		}
+		b.pending.Add(1)
+		select {
+		case b.tasks <- c:
+		default:
+			// queue is full
+			b.buildNodeDownwards(c)
+			b.pending.Done()
 		}
 	}
-	n.points = nil
-	// compute children
-	for _, c := range n.children {
-		// Only launch a goroutine for big height. we don't want a goroutine to sort 4 points
-		if n.height > MAX_HEIGHT_TO_SPLIT {
-			confirmCh <- 1
-			go r.buildNodeDownwards(c, confirmCh, true, false)
-		} else {
-			r.buildNodeDownwards(c, confirmCh, false, false)
+}
+
+// sort length items from offset into slabs along dim, then each slab along the next dimension
+func (b *builder[C, E]) buildSlabs(n *rnode[C, E], offset, length, dim, childSize, slabs int, isSorted bool, children *[]buildTask[C, E]) {
+	t := b.t
+	// size of the slabs along dim, so that the last dimension ends up with slabs of childSize
+	size := childSize * int(math.Pow(float64(slabs), float64(t.dims-1-dim)))
+	// root node might already be sorted. In that case we avoid double computation
+	if !isSorted {
+		FloydRivestBuckets(b.sorter(offset, length, dim), size, 0, length-1)
+	}
+	for i := 0; i < length; i += size {
+		end := minInt(i+size, length)
+		if dim < t.dims-1 {
+			b.buildSlabs(n, offset+i, end-i, dim+1, childSize, slabs, false, children)
+			continue
 		}
+		child := &rnode[C, E]{
+			height: n.height - 1,
+			cow:    t.cow,
+		}
+		n.children = append(n.children, child)
+		*children = append(*children, buildTask[C, E]{n: child, offset: offset + i, length: end - i})
 	}
 }
 
+func (b *builder[C, E]) sorter(offset, length, dim int) entrySorter[C, E] {
+	stride := 2 * b.t.dims
+	return entrySorter[C, E]{
+		coordinates: b.coordinates[offset*stride : (offset+length)*stride],
+		values:      b.values[offset : offset+length],
+		stride:      stride,
+		dim:         dim,
+	}
+}
 
-func (r *RBush) InsertElement(p Interface) {
-	x1, y1, x2, y2 := p.GetBBoxAt(0)
-	node := Node{
-		points: p,
-		BBox: BBox{
-			MinX: x1,
-			MaxX: x2,
-			MinY: y1,
-			MaxY: y2,
-		},
+// leaves keep their part of the loaded items, capped so that appending to a leaf does not overwrite the next one
+func (b *builder[C, E]) setLeafNode(n *rnode[C, E], offset, length int) {
+	stride := 2 * b.t.dims
+	n.coordinates = b.coordinates[offset*stride : (offset+length)*stride : (offset+length)*stride]
+	n.values = b.values[offset : offset+length : offset+length]
+	n.height = 1
+	n.isLeaf = true
+}
+
+// add a single item, checking that the tree can be modified
+func (t *rtree[C, E]) insertItem(box []C, value E) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.insert(box, value)
+}
+
+func (t *rtree[C, E]) insert(box []C, value E) error {
+	path, err := t.chooseSubtree(box, 0)
+	if err != nil {
+		return err
 	}
-	// TODO make sure this actually works
-	r.insertNode(&node)
+	leaf := path[len(path)-1]
+	leaf.coordinates = append(leaf.coordinates, box...)
+	leaf.values = append(leaf.values, value)
+	t.splitUpwards(path, box)
+	return nil
 }
 
-func (r *RBush) insertNode(n *Node) {
+func (t *rtree[C, E]) insertNode(n *rnode[C, E]) error {
 	// insert small tree into big tree
-	chosenNode := r.chooseSubtree(n)
-	// TODO probably do something in the case : n.isLeaf, chosenNode.isLeaf
-	n.parentNode = chosenNode
+	path, err := t.chooseSubtree(n.bbox, n.height)
+	if err != nil {
+		return err
+	}
+	chosenNode := path[len(path)-1]
 	chosenNode.children = append(chosenNode.children, n)
-	chosenNode.BBox = chosenNode.BBox.extend(n.BBox)
+	t.splitUpwards(path, n.bbox)
+	return nil
+}
 
-	// split on node overflow, propagate upwards
-	for iterNode := chosenNode; iterNode != nil; iterNode = iterNode.parentNode {
-		if len(iterNode.children) > r.options.MAX_ENTRIES {
-			r.split(iterNode)
+// extend bboxes with the inserted one, split on node overflow, propagate upwards
+func (t *rtree[C, E]) splitUpwards(path []*rnode[C, E], box []C) {
+	for level := len(path) - 1; level >= 0; level-- {
+		iterNode := path[level]
+		if iterNode.numEntries() > t.options.MAX_ENTRIES {
+			t.split(path, level)
 		} else {
-			iterNode.BBox = iterNode.BBox.extend(n.BBox)
+			boxExtend(iterNode.bbox, box)
 		}
 	}
-
 }
 
-func (r *RBush) splitRoot(n *Node) {
-	newRoot := Node{
-		height: r.rootNode.height + 1,
-		children: []*Node{
-			r.rootNode,
+func (t *rtree[C, E]) splitRoot(n *rnode[C, E]) {
+	newRoot := rnode[C, E]{
+		height: t.rootNode.height + 1,
+		children: []*rnode[C, E]{
+			t.rootNode,
 			n,
 		},
+		bbox: append([]C(nil), t.rootNode.bbox...),
+		cow:  t.cow,
 	}
-	r.rootNode.parentNode = &newRoot
-	n.parentNode = &newRoot
-	r.rootNode = &newRoot
+	boxExtend(newRoot.bbox, n.bbox)
+	t.rootNode = &newRoot
 }
 
-func (n *Node) setLeafNode(p Interface) {
-	// Here we follow original rbush implementation.
-	// TODO try to store elements children as points instead of nodes
-	// It seems a bit inefficient to have one child for each point, but otherwise the complexity of the code blows up
-	children := make([]*Node, p.Len())
-	n.children = children
-	n.points = nil
-	n.height = 1
-	n.isLeaf = true
+// split node at level of the path into two, update bboxes
+func (t *rtree[C, E]) split(path []*rnode[C, E], level int) {
+	n := path[level]
+	m := t.minEntries()
+	M := n.numEntries()
+	// room for the two bboxes compared at each step
+	scratch := make([]C, 2*len(n.bbox))
+	n.chooseSplitAxis(m, M, scratch)
+	i := n.chooseSplitIndex(m, M, scratch)
+	newNode := rnode[C, E]{
+		height: n.height,
+		isLeaf: n.isLeaf,
+		bbox:   make([]C, len(n.bbox)),
+		cow:    t.cow,
+	}
+	if n.isLeaf {
+		stride := len(n.bbox)
+		newNode.coordinates = append([]C{}, n.coordinates[i*stride:]...)
+		newNode.values = append([]E{}, n.values[i:]...)
+		n.coordinates = n.coordinates[0 : i*stride]
+		n.values = n.values[0:i]
+	} else {
+		newNode.children = append([]*rnode[C, E]{}, n.children[i:]...)
+		n.children = n.children[0:i]
+	}
+	n.updateBBox()
+	newNode.updateBBox()
+	// not root
+	if level > 0 {
+		parent := path[level-1]
+		parent.children = append(parent.children, &newNode)
+	} else {
+		t.splitRoot(&newNode)
+	}
+
+}
+
+// minimum number of entries of a node after a split or a removal. Unless configured, same ratio as rbush, 40% of max entries
+func (t *rtree[C, E]) minEntries() int {
+	return t.options.minEntries()
+}
+
+func (o Options) minEntries() int {
+	if o.MIN_ENTRIES != 0 {
+		return o.MIN_ENTRIES
+	}
+	return max(2, int(math.Ceil(float64(o.MAX_ENTRIES)*0.4)))
+}
 
-	for i := 0; i < p.Len(); i++ {
-		x1, y1, x2, y2 := p.GetBBoxAt(i)
-		children[i] = &Node{
-			points: p.Slice(i, i+1),
-			BBox: BBox{
-				MinX: x1,
-				MaxX: x2,
-				MinY: y1,
-				MaxY: y2,
-			},
-			parentNode: n,
+// sorts children by best axis for split. The best axis is the one with minimum total margin
+// among all the possible distributions. On ties the later axis is kept, entries end up sorted by the last one
+func (n *rnode[C, E]) chooseSplitAxis(m, M int, scratch []C) {
+	dims := len(n.bbox) / 2
+	best := -1
+	minMargin := 0.0
+	for d := 0; d < dims; d++ {
+		margin := n.allDistMargin(m, M, d, scratch)
+		if best == -1 || !(minMargin < margin) {
+			best = d
+			minMargin = margin
 		}
 	}
+	// if total distributions margin value is minimal for another axis sort by it,
+	// otherwise it's already sorted by the last one
+	if best != dims-1 {
+		n.sortEntries(best)
+	}
 }
 
-// split node into two, update bboxes
-func (r *RBush) split(n *Node) {
-	n.chooseSplitAxis()
-	i := n.chooseSplitIndex()
-	newNode := Node{
-		children:   n.children[i:len(n.children)],
-		height:     n.height,
-		parentNode: n.parentNode,
-		isLeaf:     n.isLeaf,
+// total margin of all possible split distributions where each node is at least m full
+func (n *rnode[C, E]) allDistMargin(m, M, dim int, scratch []C) float64 {
+	n.sortEntries(dim)
+	stride := len(n.bbox)
+	leftBBox := n.partialBBox(scratch[:stride], 0, m)
+	rightBBox := n.partialBBox(scratch[stride:], M-m, M)
+	margin := boxMargin(leftBBox) + boxMargin(rightBBox)
+	for i := m; i < M-m; i++ {
+		boxExtend(leftBBox, n.entryBox(i))
+		margin += boxMargin(leftBBox)
 	}
-	n.children = n.children[0:i]
-	for _, c := range newNode.children {
-		c.parentNode = &newNode
+	for i := M - m - 1; i >= m; i-- {
+		boxExtend(rightBBox, n.entryBox(i))
+		margin += boxMargin(rightBBox)
 	}
-	n.BBox = n.partialBBox(0, len(n.children))
-	newNode.BBox = newNode.partialBBox(0, len(newNode.children))
-	// not root
-	if n.parentNode != nil {
-		n.parentNode.children = append(n.parentNode.children, &newNode)
-	} else {
-		r.splitRoot(&newNode)
+	return margin
+}
+
+// find best index to split. Children are expected to be sorted along the split axis
+func (n *rnode[C, E]) chooseSplitIndex(m, M int, scratch []C) int {
+	index := -1
+	minOverlap := math.Inf(+1)
+	minArea := math.Inf(+1)
+	stride := len(n.bbox)
+	for i := m; i <= M-m; i++ {
+		bbox1 := n.partialBBox(scratch[:stride], 0, i)
+		bbox2 := n.partialBBox(scratch[stride:], i, M)
+		overlap := boxIntersectionVolume(bbox1, bbox2)
+		area := boxVolume(bbox1) + boxVolume(bbox2)
+		// choose distribution with minimum overlap
+		if overlap < minOverlap {
+			minOverlap = overlap
+			index = i
+			if area < minArea {
+				minArea = area
+			}
+		} else if overlap == minOverlap {
+			// otherwise choose distribution with minimum area
+			if area < minArea {
+				minArea = area
+				index = i
+			}
+		}
 	}
+	if index == -1 {
+		// areas might be NaN for infinite boxes
+		return M - m
+	}
+	return index
+}
 
+// sort the entries by their minimum along dim
+func (n *rnode[C, E]) sortEntries(dim int) {
+	if n.isLeaf {
+		sort.Sort(entrySorter[C, E]{coordinates: n.coordinates, values: n.values, stride: len(n.bbox), dim: dim})
+		return
+	}
+	sort.Slice(n.children, func(i, j int) bool {
+		return n.children[i].bbox[dim] < n.children[j].bbox[dim]
+	})
+}
+
+// number of items for leaves, number of children otherwise
+func (n *rnode[C, E]) numEntries() int {
+	if n.isLeaf {
+		return len(n.values)
+	}
+	return len(n.children)
 }
 
-// sorts children by best axis for split
-func (n *Node) chooseSplitAxis() {
-	// TODO do properly
+// bbox of the i-th item of a leaf or the i-th child
+func (n *rnode[C, E]) entryBox(i int) []C {
+	if n.isLeaf {
+		stride := len(n.bbox)
+		return n.coordinates[i*stride : (i+1)*stride : (i+1)*stride]
+	}
+	return n.children[i].bbox
 }
 
-// find best index to split
-func (n *Node) chooseSplitIndex() int {
-	// TODO do properly
-	return len(n.children) / 2
+// remove the i-th item of a leaf
+func (n *rnode[C, E]) removeItem(i int) {
+	stride := len(n.bbox)
+	n.coordinates = append(n.coordinates[0:i*stride], n.coordinates[(i+1)*stride:]...)
+	n.values = append(n.values[0:i], n.values[i+1:]...)
 }
 
 // find optimal node searching for the node that grows less in area.
-func (r *RBush) chooseSubtree(n *Node) *Node {
+// height is 0 for items. Returns the path from the root to the chosen node, all of them owned by the tree
+func (t *rtree[C, E]) chooseSubtree(box []C, height int) ([]*rnode[C, E], error) {
 	// -1 because we want the node to be at the same level
-	// n.height same as rootNode.height is not considered here since we would have called split root
-	requiredDepth := r.rootNode.height - n.height - 1
+	// height same as rootNode.height is not considered here since we would have called split root
+	requiredDepth := t.rootNode.height - height - 1
 	if requiredDepth < 0 {
 		// Most definitely an error in the implementation
-		log.Fatal("We are inserting a big tree into a smaller tree.")
+		return nil, &TreeError{Op: "insert", Msg: "inserting a big tree into a smaller tree"}
 	}
 	depth := 0
-	chosenNode := r.rootNode
+	t.rootNode = t.mutable(t.rootNode)
+	chosenNode := t.rootNode
+	path := []*rnode[C, E]{chosenNode}
 	for true {
 		// We always insert small tree into big tree so it cannot happen that we insert a non point into a leaf
-		if chosenNode.isLeaf || depth == requiredDepth {
+		if depth == requiredDepth {
 			break
 		}
+		if chosenNode.isLeaf {
+			return nil, &TreeError{Op: "insert", Msg: "reached a leaf above the required depth"}
+		}
+		if len(chosenNode.children) == 0 {
+			return nil, &TreeError{Op: "insert", Msg: "non leaf node without children"}
+		}
 		minArea := math.Inf(+1)
 		minEnlargement := math.Inf(+1)
-		var targetNode *Node
-		for _, child := range chosenNode.children {
-			area := child.BBox.area()
-			enlargement := n.BBox.enlargedArea(child.BBox) - area
+		targetIndex := -1
+		for j, child := range chosenNode.children {
+			area := boxVolume(child.bbox)
+			enlargement := boxEnlargedVolume(box, child.bbox) - area
 
 			// find entry with minimum enlargment
 			if enlargement < minEnlargement {
@@ -375,163 +873,173 @@ func (r *RBush) chooseSubtree(n *Node) *Node {
 				if area < minArea {
 					minArea = area
 				}
-				targetNode = child
+				targetIndex = j
 			} else if enlargement == minEnlargement {
 				if area < minArea {
 					minArea = area
-					targetNode = child
+					targetIndex = j
 				}
 			}
 		}
-		if targetNode != nil {
-			chosenNode = targetNode
-		} else {
+		if targetIndex == -1 {
 			// in case we cannot choose among all children (for example if area is infinity then we chose first child)
-			chosenNode = chosenNode.children[0]
+			targetIndex = 0
 		}
+		chosenNode = t.mutableChild(chosenNode, targetIndex)
+		path = append(path, chosenNode)
 		depth++
 	}
-	return chosenNode
+	return path, nil
 
 }
 
 // Compute bbox of all tree all the way to the bottom
-func (n *Node) computeBBoxDownwards() BBox {
-
-	var bbox BBox
-	if n.isLeaf {
-		bbox = BBox{
-			MinX: math.Inf(+1),
-			MaxX: math.Inf(-1),
-			MinY: math.Inf(+1),
-			MaxY: math.Inf(-1),
-		}
-		// This bounded boxes are computed when creating the nodes, they only contain one point so there is no doubt
-		for i := 0; i < len(n.children); i++ {
-			bbox = bbox.extend(n.children[i].BBox)
-		}
-	} else {
-		bbox = n.children[0].computeBBoxDownwards()
-
-		for i := 1; i < len(n.children); i++ {
-			bbox = bbox.extend(n.children[i].computeBBoxDownwards())
+func (n *rnode[C, E]) computeBBoxDownwards() {
+	if !n.isLeaf {
+		for _, c := range n.children {
+			c.computeBBoxDownwards()
 		}
 	}
-	n.BBox = bbox
-	return bbox
+	n.updateBBox()
 }
 
-// compute bbox of part of the childre
-func (n *Node) partialBBox(start, end int) BBox {
-	bbox := n.children[start].BBox
-	for i := start + 1; i < end; i++ {
-		bbox = bbox.extend(n.children[i].BBox)
+// compute into dst the bbox of part of the children or items
+func (n *rnode[C, E]) partialBBox(dst []C, start, end int) []C {
+	resetBox(dst)
+	for i := start; i < end; i++ {
+		boxExtend(dst, n.entryBox(i))
 	}
-	return bbox
+	return dst
 }
 
-func (r *RBush) Clear() {
-
+// remove the item with the bbox for which match returns true, reports whether it was found
+func (t *rtree[C, E]) remove(box []C, match func(E) bool) (bool, error) {
+	if err := t.checkWritable(); err != nil {
+		return false, err
+	}
+	indexes := t.findItem(box, match)
+	if indexes == nil {
+		return false, nil
+	}
+	path := t.mutablePath(indexes[:len(indexes)-1])
+	path[len(path)-1].removeItem(indexes[len(indexes)-1])
+	return true, t.condense(path)
 }
 
-func (r *RBush) initRootNode() {
-	r.rootNode = &Node{
-		children: []*Node{},
-		BBox: BBox{
-			MinX: math.Inf(1),
-			MaxX: math.Inf(-1),
-			MinY: math.Inf(1),
-			MaxY: math.Inf(-1),
-		},
-		isLeaf: true,
-		height: 1,
+// find the item with the bbox for which match returns true. Returns the index of the child to follow at each level
+// and the index of the item in the leaf, nil if it is not found
+func (t *rtree[C, E]) findItem(box []C, match func(E) bool) []int {
+	indexes := make([]int, 0, t.rootNode.height)
+	if findItemDownwards(t.rootNode, box, match, &indexes) {
+		return indexes
 	}
+	return nil
 }
 
-
-func (r *RBush) Remove(p ToBeRemoved) (*RBush){
-	x1, y1, x2, y2 := p.GetBBox()
-	bbox := BBox{x1, y1, x2, y2}
-	node := r.rootNode
-	nodesToSearch := make([]*Node, 0, 1)
-	indexes := make([]int, 0, r.rootNode.height) // keep track of indices to clean
-	currentIndex := 0 // index at current level
-	isGoingUp := false
-	nodesToSearch = append(nodesToSearch, node)
-	for node != nil {
-		if (node.isLeaf) {
-			index := node.findIndexToRemove(p)
-			if (index != -1) {
-				indexes = append(indexes, currentIndex, index)
-				// remove first index because we always have 0 for root node
-				node.children[index].removeUpwards(indexes[1:])
-				return r
+func findItemDownwards[C Coordinate, E any](n *rnode[C, E], box []C, match func(E) bool, indexes *[]int) bool {
+	if n.isLeaf {
+		// Maybe we can do something fancier since points might be ordered
+		for i, v := range n.values {
+			if boxEquals(box, n.entryBox(i)) && match(v) {
+				*indexes = append(*indexes, i)
+				return true
 			}
 		}
-
-		if (!isGoingUp && !node.isLeaf && node.BBox.contains(bbox)) { // go down
-			indexes = append(indexes, currentIndex)
-			currentIndex = 0
-			node = node.children[0] // TODO check size 0
-		} else if node.parentNode != nil && currentIndex < len(node.parentNode.children) - 1 { // go sideways
-			currentIndex++
-			node = node.parentNode.children[currentIndex]
-			isGoingUp = false
-		} else {
-			// we found nothing. Go up
-			node = node.parentNode
-			isGoingUp = true
-			indexes, currentIndex = indexes[0: len(indexes) -1], indexes[len(indexes) -1]
+		return false
+	}
+	for i, c := range n.children {
+		if !boxContains(c.bbox, box) {
+			continue
 		}
+		*indexes = append(*indexes, i)
+		if findItemDownwards(c, box, match, indexes) {
+			return true
+		}
+		*indexes = (*indexes)[:len(*indexes)-1]
 	}
-	return r
+	return false
 }
 
-func (n *Node) findIndexToRemove (p ToBeRemoved) (int) {
-	index := -1
-	x1, y1, x2, y2 := p.GetBBox()
-	bbox := BBox{x1, y1, x2, y2}
-	// Maybe we can do something fancier since points might be ordered
-	for i, c := range(n.children) {
-		if bbox.equals(c.BBox) && p.IsContained(c.points) {
-			return i
-		}
+// path from the root following the children indexes, copying shared nodes so that they can be modified
+func (t *rtree[C, E]) mutablePath(indexes []int) []*rnode[C, E] {
+	t.rootNode = t.mutable(t.rootNode)
+	path := make([]*rnode[C, E], 0, len(indexes)+1)
+	path = append(path, t.rootNode)
+	for _, i := range indexes {
+		path = append(path, t.mutableChild(path[len(path)-1], i))
 	}
-	return index
+	return path
 }
 
-// Remove node from parent and condense if necessary. Update bboxes
-// indexes represent indexes in parent
-func (n *Node) removeUpwards(indexes []int) {
-	nodeToRemove := n
-	parent := n.parentNode
-	for i := len(indexes) - 1; i >= 0; i-- {
-		index := indexes[i]
-		if nodeToRemove != nil {
-			var removedNode *Node
-			removedNode = parent.children[index]
-			parent.children = append(parent.children[0: index], parent.children[index + 1: len(parent.children)]...)
-			if (nodeToRemove != removedNode) {
-				log.Fatal("Removed incorrect node")
+// Walk up the path from a node that lost a child. Nodes with less than the minimum entries are removed from the tree
+// and their children reinserted, bboxes are updated and the root is collapsed while it has a single child
+func (t *rtree[C, E]) condense(path []*rnode[C, E]) error {
+	m := t.minEntries()
+	orphans := make([]*rnode[C, E], 0)
+	for level := len(path) - 1; level > 0; level-- {
+		node := path[level]
+		parent := path[level-1]
+		if node.numEntries() < m {
+			index := parent.indexOf(node)
+			if index == -1 {
+				return &TreeError{Op: "remove", Msg: "node is not a child of its parent"}
+			}
+			parent.children = append(parent.children[0:index], parent.children[index+1:]...)
+			orphans = append(orphans, node)
+		} else {
+			node.updateBBox()
+		}
+	}
+	if t.rootNode.numEntries() == 0 {
+		t.initRootNode()
+	} else {
+		t.rootNode.updateBBox()
+	}
+
+	for _, o := range orphans {
+		for i, v := range o.values {
+			if err := t.insert(o.entryBox(i), v); err != nil {
+				return err
 			}
-			if len(parent.children) == 0 {
-				nodeToRemove = parent
-			} else {
-				nodeToRemove = nil
+		}
+		for _, c := range o.children {
+			if err := t.reinsert(c); err != nil {
+				return err
 			}
 		}
-		parent.BBox = parent.partialBBox(0, len(parent.children))
-		parent = parent.parentNode
 	}
+
+	for !t.rootNode.isLeaf && len(t.rootNode.children) == 1 {
+		t.rootNode = t.rootNode.children[0]
+	}
+	return nil
 }
 
-type ToBeRemoved interface {
-	GetBBox () (x1, y1, x2, y2 float64)
-	IsContained (points Interface) bool
+// insert back an entry of a dissolved node at its own level if the tree is still tall enough
+func (t *rtree[C, E]) reinsert(n *rnode[C, E]) error {
+	if n.height < t.rootNode.height {
+		return t.insertNode(n)
+	}
+	var err error
+	n.walk(nil, true, func(leaf *rnode[C, E], i int) bool {
+		err = t.insert(leaf.entryBox(i), leaf.values[i])
+		return err == nil
+	})
+	return err
 }
 
-func (r RBush) ToBBox() {
+func (n *rnode[C, E]) indexOf(child *rnode[C, E]) int {
+	for i, c := range n.children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
 
+// recompute bbox from children, in place
+func (n *rnode[C, E]) updateBBox() {
+	n.partialBBox(n.bbox, 0, n.numEntries())
 }
 
 func minInt(a, b int) int {
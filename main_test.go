@@ -1,9 +1,13 @@
 package go_rbush
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"sync/atomic"
 	"testing"
 )
 
@@ -87,13 +91,22 @@ func TestRBush_Search_big_coordinates(t *testing.T) {
 	}
 }
 
+func TestRBush_LoadDoesNotReorder(t *testing.T) {
+	data := getData(1000, 1)
+	original := append(bboxes{}, data...)
+	New().Load(data)
+	for i := range data {
+		assertEqual(t, data[i], original[i], "")
+	}
+}
+
 func TestRBush_Load(t *testing.T) {
 	data := getDataExample()
 	originalData := getDataExample()
 	childNodes := New().Load(data).rootNode.flattenDownwards()
 	recoveredPoints := make([][4]float64, 0, len(childNodes))
 	for _, n := range childNodes {
-		b := [][4]float64(n.points.(bboxes))
+		b := [][4]float64(n.value().(bboxes))
 		recoveredPoints = append(recoveredPoints, b...)
 	}
 
@@ -138,7 +151,7 @@ func TestRBush_Loading_little_data(t *testing.T) {
 
 func TestRBush_LoadNothing(t *testing.T) {
 	tree1 := New().Load(make(coordinates, 0))
-	assertEqual(t, len(tree1.rootNode.children), 0, "Root has not children on init mode")
+	assertEqual(t, tree1.rootNode.numEntries(), 0, "Root has not children on init mode")
 }
 
 func TestRBush_LoadEmptyData(t *testing.T) {
@@ -356,11 +369,11 @@ func TestRBush_Remove(t *testing.T) {
 	}
 }
 
-func getTreePointsAsCoordinates(n *Node) [][4]float64 {
+func getTreePointsAsCoordinates(n *rbushNode) [][4]float64 {
 	childNodes := n.flattenDownwards()
 	recoveredPoints := make([][4]float64, 0, len(childNodes))
 	for _, c := range childNodes {
-		b := [][4]float64(c.points.(bboxes))
+		b := [][4]float64(c.value().(bboxes))
 		recoveredPoints = append(recoveredPoints, b...)
 	}
 	sort.Sort(bboxes(recoveredPoints))
@@ -467,3 +480,466 @@ func getEmptyDataExample() bboxes {
 		{math.Inf(-1), math.Inf(-1), math.Inf(+1), math.Inf(+1)}, {math.Inf(-1), math.Inf(-1), math.Inf(+1), math.Inf(+1)},
 		{math.Inf(-1), math.Inf(-1), math.Inf(+1), math.Inf(+1)}, {math.Inf(-1), math.Inf(-1), math.Inf(+1), math.Inf(+1)}}
 }
+
+func TestRBush_InsertElementBuildsTreeComparableToLoad(t *testing.T) {
+	data := getData(5000, 1)
+	loaded := NewWithOptions(Options{MAX_ENTRIES: 16}).Load(append(bboxes{}, data...))
+	inserted := NewWithOptions(Options{MAX_ENTRIES: 16})
+	for i := range data {
+		inserted.InsertElement(data[i : i+1])
+	}
+	assertEqual(t, len(getTreePointsAsCoordinates(inserted.rootNode)), len(data), "")
+
+	loadedArea := leafArea(loaded.rootNode)
+	insertedArea := leafArea(inserted.rootNode)
+	// Splitting by the middle of unsorted children produces leaves that span the whole space
+	if insertedArea > 2*loadedArea {
+		t.Errorf("Inserted tree leaves cover too much area %v, loaded tree %v", insertedArea, loadedArea)
+	}
+
+	queries := getData(200, 10)
+	loadedVisits, insertedVisits := 0, 0
+	for _, q := range queries {
+		b := BBox{q[0], q[1], q[2], q[3]}
+		loadedVisits += countVisitedNodes(loaded.rootNode, b)
+		insertedVisits += countVisitedNodes(inserted.rootNode, b)
+		assertEqual(t, len(inserted.Search(b)), len(loaded.Search(b)), "")
+	}
+	if insertedVisits > 2*loadedVisits {
+		t.Errorf("Inserted tree visits too many nodes %v, loaded tree %v", insertedVisits, loadedVisits)
+	}
+}
+
+func TestRBush_SplitKeepsMinimumFill(t *testing.T) {
+	data := getData(1000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9})
+	for i := range data {
+		tree.InsertElement(data[i : i+1])
+	}
+	nodes := []*rbushNode{tree.rootNode}
+	for len(nodes) != 0 {
+		n := nodes[0]
+		nodes = nodes[1:]
+		if n != tree.rootNode && (n.numEntries() < tree.minEntries() || n.numEntries() > 9) {
+			t.Errorf("Node with %v children", n.numEntries())
+		}
+		if !n.isLeaf {
+			nodes = append(nodes, n.children...)
+		}
+	}
+}
+
+// sum of the areas of all leaf nodes
+func leafArea(n *rbushNode) float64 {
+	if n.isLeaf {
+		return boxVolume(n.bbox)
+	}
+	area := 0.
+	for _, c := range n.children {
+		area += leafArea(c)
+	}
+	return area
+}
+
+// number of nodes whose children need to be checked to answer a search
+func countVisitedNodes(n *rbushNode, b BBox) int {
+	q := b.flat()
+	if !boxIntersects(n.bbox, q[:]) {
+		return 0
+	}
+	count := 1
+	if !n.isLeaf {
+		for _, c := range n.children {
+			count += countVisitedNodes(c, b)
+		}
+	}
+	return count
+}
+
+func TestRBush_SearchItems(t *testing.T) {
+	data := getDataExample()
+	features := make(featureList, len(data))
+	for i, d := range data {
+		features[i] = feature{id: i, bbox: d}
+	}
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(features)
+	items := tree.SearchItems(BBox{40, 20, 80, 70})
+	assertEqual(t, len(items), 12, "")
+	for _, item := range items {
+		assertEqual(t, item.Len(), 1, "")
+		f := item.(featureList)[0]
+		// we can map back to our original data
+		assertEqual(t, f.bbox, data[f.id], "")
+	}
+}
+
+func TestNode_Accessors(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	nodes := tree.Search(BBox{0, 0, 100, 100})
+	assertEqual(t, len(nodes), len(data), "")
+	for _, n := range nodes {
+		assertEqual(t, n.Points().Len(), 1, "")
+		assertEqual(t, interfaceBBox(n.Points()), n.BBox, "")
+	}
+}
+
+// collection of domain items, as a user would store in the index
+type feature struct {
+	id   int
+	bbox [4]float64
+}
+
+type featureList []feature
+
+func (c featureList) GetBBoxAt(i int) (x1, y1, x2, y2 float64) {
+	return c[i].bbox[0], c[i].bbox[1], c[i].bbox[2], c[i].bbox[3]
+}
+
+func (c featureList) Len() int {
+	return len(c)
+}
+
+func (c featureList) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+func (c featureList) Slice(i, j int) Interface {
+	return c[i:j]
+}
+
+func TestRBush_Clear(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	tree.Clear()
+	assertEqual(t, len(tree.All()), 0, "")
+	assertEqual(t, tree.rootNode.height, 1, "")
+	assertEqual(t, tree.rootNode.isLeaf, true, "")
+	assertEqual(t, len(tree.Search(BBox{0, 0, 100, 100})), 0, "")
+
+	// tree can be reused after clear
+	data := getDataExample()
+	tree.Load(data[0:10])
+	for i := 10; i < len(data); i++ {
+		tree.InsertElement(data[i : i+1])
+	}
+	assertEqual(t, len(tree.All()), len(data), "")
+	tree.Clear()
+	assertEqual(t, len(tree.All()), 0, "")
+}
+
+func TestRBush_ToBBox(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	assertEqual(t, tree.ToBBox(), BBox{0, 0, 95, 95}, "")
+
+	data := getDataExample()
+	tree = NewWithOptions(Options{MAX_ENTRIES: 4})
+	for i := 0; i < 10; i++ {
+		tree.InsertElement(data[i : i+1])
+	}
+	assertEqual(t, tree.ToBBox(), BBox{0, 0, 45, 45}, "")
+
+	tree.Clear()
+	assertEqual(t, tree.ToBBox(), BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}, "")
+}
+
+func TestRBush_All(t *testing.T) {
+	data := getDataExample()
+	expected := getDataExample()
+	sort.Sort(expected)
+
+	loaded := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	inserted := NewWithOptions(Options{MAX_ENTRIES: 4})
+	for i := range expected {
+		inserted.InsertElement(expected[i : i+1])
+	}
+	for _, tree := range []*RBush{loaded, inserted} {
+		nodes := tree.All()
+		result := make(bboxes, len(nodes))
+		for i, n := range nodes {
+			result[i] = n.Points().(bboxes)[0]
+		}
+		sort.Sort(result)
+		assertEqual(t, len(result), len(expected), "")
+		for i := range result {
+			assertEqual(t, result[i], expected[i], "")
+		}
+	}
+	assertEqual(t, len(New().All()), 0, "")
+}
+
+func TestRBush_RemoveElementReportsRemoval(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	item := data[5]
+	assertEqual(t, removeElement(t, tree, bboxToRemove(item)), true, "")
+	assertEqual(t, removeElement(t, tree, bboxToRemove(item)), false, "")
+	assertEqual(t, removeElement(t, tree, bboxToRemove{200, 200, 200, 200}), false, "")
+	assertEqual(t, len(tree.All()), len(data)-1, "")
+}
+
+func TestRBush_RemoveCondensesTree(t *testing.T) {
+	data := getData(1000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4})
+	for i := range data {
+		tree.InsertElement(data[i : i+1])
+	}
+	initialHeight := tree.rootNode.height
+	for i := 0; i < len(data)-10; i++ {
+		assertEqual(t, removeElement(t, tree, bboxToRemove(data[i])), true, "")
+		if i%50 == 0 {
+			assertMinimumFill(t, tree)
+		}
+	}
+	assertMinimumFill(t, tree)
+	if tree.rootNode.height >= initialHeight {
+		t.Errorf("Tree should shrink after removing most items, height %v", tree.rootNode.height)
+	}
+
+	remaining := data[len(data)-10:]
+	expected := append(bboxes{}, remaining...)
+	sort.Sort(expected)
+	result := getTreePointsAsCoordinates(tree.rootNode)
+	assertEqual(t, len(result), len(expected), "")
+	for i := range result {
+		assertEqual(t, result[i], expected[i], "")
+	}
+	for _, d := range remaining {
+		assertEqual(t, len(tree.Search(BBox{d[0], d[1], d[2], d[3]})) > 0, true, "")
+	}
+
+	for _, d := range remaining {
+		tree.Remove(bboxToRemove(d))
+	}
+	assertEqual(t, len(tree.All()), 0, "")
+	assertEqual(t, tree.rootNode.height, 1, "")
+	assertEqual(t, tree.rootNode.isLeaf, true, "")
+}
+
+func TestRBush_RemoveFromLoadedTree(t *testing.T) {
+	data := getData(1000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data...))
+	for i := 0; i < 900; i++ {
+		assertEqual(t, removeElement(t, tree, bboxToRemove(data[i])), true, "")
+	}
+	assertEqual(t, len(tree.All()), 100, "")
+	for _, d := range data[900:] {
+		assertEqual(t, removeElement(t, tree, bboxToRemove(d)), true, "")
+	}
+	assertEqual(t, len(tree.All()), 0, "")
+}
+
+func removeElement(t *testing.T, tree *RBush, p ToBeRemoved) bool {
+	removed, err := tree.RemoveElement(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return removed
+}
+
+// every node apart from root has at least min entries and bboxes wrap its children
+func assertMinimumFill(t *testing.T, tree *RBush) {
+	nodes := []*rbushNode{tree.rootNode}
+	for len(nodes) != 0 {
+		n := nodes[0]
+		nodes = nodes[1:]
+		if n != tree.rootNode && n.numEntries() < tree.minEntries() {
+			t.Errorf("Node with %v children", n.numEntries())
+		}
+		if n.numEntries() != 0 && !boxEquals(n.bbox, n.partialBBox(make([]float64, 4), 0, n.numEntries())) {
+			t.Errorf("Node bbox %v does not match children", n.bbox)
+		}
+		if !n.isLeaf {
+			if len(n.children) == 1 && n == tree.rootNode {
+				t.Errorf("Root with a single child should be collapsed")
+			}
+			nodes = append(nodes, n.children...)
+		}
+	}
+}
+
+func TestRBush_SearchFunc(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	for _, b := range []BBox{{40, 20, 80, 70}, {0, 0, 100, 100}, {200, 200, 210, 210}} {
+		expected := tree.SearchItems(b)
+		result := make([]Interface, 0)
+		tree.SearchFunc(b, func(p Interface) bool {
+			result = append(result, p)
+			return true
+		})
+		assertEqual(t, len(result), len(expected), "")
+		for i := range result {
+			assertEqual(t, result[i].(bboxes)[0], expected[i].(bboxes)[0], "")
+		}
+	}
+}
+
+func TestRBush_SearchFuncStops(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	for _, b := range []BBox{{40, 20, 80, 70}, {0, 0, 100, 100}} {
+		visited := 0
+		tree.SearchFunc(b, func(p Interface) bool {
+			visited++
+			return visited < 3
+		})
+		assertEqual(t, visited, 3, "")
+	}
+}
+
+// Collides and CollidesAll must agree with Search for any tree and any bbox
+func TestRBush_CollidesMatchesSearch(t *testing.T) {
+	data := getData(1000, 1)
+	loaded := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(append(bboxes{}, data...))
+	inserted := NewWithOptions(Options{MAX_ENTRIES: 4})
+	for i := range data {
+		assertNoError(t, inserted.InsertElement(data[i:i+1]))
+	}
+	removed := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(append(bboxes{}, data...))
+	for i := 0; i < len(data); i += 3 {
+		removeElement(t, removed, bboxToRemove(data[i]))
+	}
+	trees := []*RBush{loaded, inserted, removed, New(), NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getEmptyDataExample())}
+
+	queries := make([]BBox, 0)
+	for _, size := range []float64{0, 0.5, 5, 50} {
+		for _, q := range getData(200, size) {
+			queries = append(queries, BBox{q[0], q[1], q[2], q[3]})
+		}
+	}
+	// bboxes touching items, degenerate and infinite ones
+	for _, d := range data[0:50] {
+		queries = append(queries, BBox{d[2], d[3], d[2] + 1, d[3] + 1}, BBox{d[0] - 1, d[1] - 1, d[0], d[1]}, BBox{d[0], d[1], d[0], d[1]})
+	}
+	queries = append(queries, BBox{math.Inf(-1), math.Inf(-1), math.Inf(1), math.Inf(1)}, BBox{1, 1, 0, 0}, BBox{-10, -10, -5, -5})
+
+	for _, tree := range trees {
+		all := tree.CollidesAll(queries)
+		assertEqual(t, len(all), len(queries), "")
+		for i, q := range queries {
+			expected := len(tree.Search(q)) > 0
+			assertEqual(t, tree.Collides(q), expected, fmt.Sprintf("Collides(%v) should be %v", q, expected))
+			assertEqual(t, all[i], expected, fmt.Sprintf("CollidesAll for %v should be %v", q, expected))
+		}
+	}
+}
+
+func TestRBush_SearchResultsAreNotReused(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getDataExample())
+	first := tree.Search(BBox{0, 0, 100, 100})
+	expected := make([]BBox, len(first))
+	for i, n := range first {
+		expected[i] = n.BBox
+	}
+	// buffers used by the first search are reused by the second one
+	second := tree.Search(BBox{0, 0, 10, 10})
+	assertEqual(t, len(second), 2, "")
+	assertEqual(t, len(first), len(getDataExample()), "")
+	for i, n := range first {
+		assertEqual(t, n.BBox, expected[i], "")
+		assertEqual(t, n.Points().(bboxes)[0], [4]float64{n.BBox.MinX, n.BBox.MinY, n.BBox.MaxX, n.BBox.MaxY}, "")
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	valid := []Options{{MAX_ENTRIES: 4}, {MAX_ENTRIES: 9}, {MAX_ENTRIES: 9, MIN_ENTRIES: 2}, {MAX_ENTRIES: 9, MIN_ENTRIES: 4}, {MAX_ENTRIES: 16, MIN_ENTRIES: 8}}
+	for _, o := range valid {
+		assertNoError(t, o.Validate())
+		tree, err := NewWithValidOptions(o)
+		assertNoError(t, err)
+		assertEqual(t, tree.options, o, "")
+	}
+	invalid := []Options{{}, {MAX_ENTRIES: 3}, {MAX_ENTRIES: -1}, {MAX_ENTRIES: 9, MIN_ENTRIES: 1}, {MAX_ENTRIES: 9, MIN_ENTRIES: 5}, {MAX_ENTRIES: 9, MIN_ENTRIES: -2}, {MAX_ENTRIES: 9, MAX_WORKERS: -1}}
+	for _, o := range invalid {
+		assertEqual(t, errors.Is(o.Validate(), ErrInvalidOptions), true, fmt.Sprintf("%v should be invalid", o))
+		tree, err := NewWithValidOptions(o)
+		assertEqual(t, errors.Is(err, ErrInvalidOptions), true, "")
+		assertEqual(t, tree, (*RBush)(nil), "")
+	}
+}
+
+func TestRBush_MinEntriesOption(t *testing.T) {
+	data := getData(1000, 1)
+	for _, m := range []int{2, 3, 8} {
+		tree := NewWithOptions(Options{MAX_ENTRIES: 16, MIN_ENTRIES: m})
+		assertEqual(t, tree.minEntries(), m, "")
+		for i := range data {
+			assertNoError(t, tree.InsertElement(data[i:i+1]))
+		}
+		assertMinimumFill(t, tree)
+		for i := 0; i < len(data); i += 2 {
+			removeElement(t, tree, bboxToRemove(data[i]))
+		}
+		assertMinimumFill(t, tree)
+		assertNoError(t, tree.Check())
+	}
+	assertEqual(t, NewWithOptions(Options{MAX_ENTRIES: 16}).minEntries(), 7, "")
+}
+
+func TestRBush_LoadAroundMinEntries(t *testing.T) {
+	// less than MIN_ENTRIES items are inserted one by one instead of building a tree
+	for n := 1; n < 20; n++ {
+		tree := NewWithOptions(Options{MAX_ENTRIES: 4, MIN_ENTRIES: 2}).Load(getSomeDataBBoxes(8))
+		tree.Load(getSomeDataBBoxes(n))
+		assertNoError(t, tree.Check())
+		assertEqual(t, len(tree.All()), 8+n, "")
+	}
+}
+
+func TestRBush_LoadWithMaxWorkers(t *testing.T) {
+	data := getData(100000, 1)
+	var expected []byte
+	for _, workers := range []int{1, 2, 4, 0, -1} {
+		tree := NewWithOptions(Options{MAX_ENTRIES: 9, MAX_WORKERS: workers}).Load(append(bboxes{}, data...))
+		assertNoError(t, tree.Check())
+		var buf bytes.Buffer
+		assertNoError(t, tree.WriteBinary(&buf, nil))
+		// the same tree is built regardless of the number of workers
+		if expected == nil {
+			expected = buf.Bytes()
+		}
+		assertEqual(t, bytes.Equal(buf.Bytes(), expected), true, fmt.Sprintf("Tree built with %v workers differs", workers))
+	}
+}
+
+func TestRBush_LoadContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(getDataExample())
+	err := tree.LoadContext(ctx, getData(10000, 1))
+	assertEqual(t, errors.Is(err, context.Canceled), true, "")
+	assertEqual(t, len(tree.All()), len(getDataExample()), "Index should be unchanged")
+	assertNoError(t, tree.Check())
+
+	for _, workers := range []int{1, 4} {
+		tree = NewWithOptions(Options{MAX_ENTRIES: 9, MAX_WORKERS: workers})
+		err = tree.LoadContext(newCancellingContext(100), getData(100000, 1))
+		assertEqual(t, errors.Is(err, context.Canceled), true, "")
+		assertEqual(t, len(tree.All()), 0, "")
+		err = tree.LoadSortedArrayContext(newCancellingContext(100), getData(100000, 1))
+		assertEqual(t, errors.Is(err, context.Canceled), true, "")
+		assertEqual(t, len(tree.All()), 0, "")
+	}
+
+	assertNoError(t, tree.LoadContext(context.Background(), getData(10000, 1)))
+	assertEqual(t, len(tree.All()), 10000, "")
+}
+
+// cancels the context after a number of checks, so the load is cancelled in the middle of building the tree
+type cancellingContext struct {
+	context.Context
+	cancel     func()
+	checksLeft atomic.Int64
+}
+
+func newCancellingContext(checks int64) *cancellingContext {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &cancellingContext{Context: ctx, cancel: cancel}
+	c.checksLeft.Store(checks)
+	return c
+}
+
+func (c *cancellingContext) Err() error {
+	if c.checksLeft.Add(-1) == 0 {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
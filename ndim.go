@@ -0,0 +1,248 @@
+package go_rbush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// InterfaceOf abstracts a collection of items with N dimensional bboxes
+type InterfaceOf[C Coordinate] interface {
+	GetBBoxAt(i int) (min, max []C) // Retrieve bbox of the item at position i, one coordinate per dimension. They are copied by the index
+	Len() int                       // Number of elements
+	Slice(i, j int) InterfaceOf[C]  // Slice the interface between two indices
+}
+
+type ToBeRemovedOf[C Coordinate] interface {
+	GetBBox() (min, max []C)
+	IsContained(points InterfaceOf[C]) bool
+}
+
+// InterfaceN is a collection of items with float64 coordinates
+type InterfaceN = InterfaceOf[float64]
+
+type ToBeRemovedN = ToBeRemovedOf[float64]
+
+// ErrDimensionMismatch is wrapped by the errors returned when a bbox does not have the dimensions of the index
+var ErrDimensionMismatch = errors.New("rbush: dimension mismatch")
+
+// RBushOf is an rbush index of N dimensional bboxes, for example 3D volumes or 2D geometries with a time range.
+// It is the generic rtree of RBush with dims dimensions, bulk loading generalizes OMT to slice the items along every dimension.
+// Coordinates can be int32 or float32 to save memory, for example RBushOf[int32] with 2 dimensions for tile coordinates
+type RBushOf[C Coordinate] struct {
+	rtree[C, itemOf[C]]
+}
+
+// RBushN is an index with float64 coordinates
+type RBushN = RBushOf[float64]
+
+// An item is the element at position index of points, its bbox is stored by the leaf holding it
+type itemOf[C Coordinate] struct {
+	points InterfaceOf[C]
+	index  int
+}
+
+// InterfaceOf of length 1 holding the item
+func (it itemOf[C]) value() InterfaceOf[C] {
+	if it.points.Len() == 1 {
+		return it.points
+	}
+	return it.points.Slice(it.index, it.index+1)
+}
+
+// NewN creates an empty index of dims dimensions with float64 coordinates and default options
+func NewN(dims int) *RBushN {
+	return NewNWithOptions(dims, Options{MAX_ENTRIES: 9})
+}
+
+func NewNWithOptions(dims int, options Options) *RBushN {
+	return NewOf[float64](dims, options)
+}
+
+// NewOf creates an empty index of dims dimensions with coordinates of type C.
+// It panics if dims is less than 1 or the options are not valid, see NewOfWithValidOptions
+func NewOf[C Coordinate](dims int, options Options) *RBushOf[C] {
+	r, err := NewOfWithValidOptions[C](dims, options)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// NewOfWithValidOptions creates an index of dims dimensions after checking that there is at least one dimension
+// and validating the options, see Options.Validate
+func NewOfWithValidOptions[C Coordinate](dims int, options Options) (*RBushOf[C], error) {
+	if dims < 1 {
+		return nil, fmt.Errorf("%w: dims is %v, it should be at least 1", ErrInvalidOptions, dims)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	return &RBushOf[C]{rtree: newRTree[C, itemOf[C]](dims, options)}, nil
+}
+
+// Dims returns the number of dimensions of the index
+func (r *RBushOf[C]) Dims() int {
+	return r.dims
+}
+
+// read the bboxes and items of the collection, the coordinates of all of them are stored in a single allocation
+func (r *RBushOf[C]) newItems(points InterfaceOf[C]) ([]C, []itemOf[C], error) {
+	n := points.Len()
+	stride := 2 * r.dims
+	coordinates := make([]C, stride*n)
+	items := make([]itemOf[C], n)
+	for i := 0; i < n; i++ {
+		min, max := points.GetBBoxAt(i)
+		if len(min) != r.dims || len(max) != r.dims {
+			return nil, nil, fmt.Errorf("%w: item %v has %v and %v coordinates, expected %v", ErrDimensionMismatch, i, len(min), len(max), r.dims)
+		}
+		c := coordinates[stride*i : stride*(i+1)]
+		copy(c, min)
+		copy(c[r.dims:], max)
+		items[i] = itemOf[C]{points: points, index: i}
+	}
+	return coordinates, items, nil
+}
+
+// Load bulk inserts the points. As RBush.Load the collection is not reordered.
+// It panics if the bboxes do not have the dimensions of the index, see LoadContext to get the error instead
+func (r *RBushOf[C]) Load(points InterfaceOf[C]) *RBushOf[C] {
+	if err := r.LoadContext(context.Background(), points); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadContext is like Load but returns an error wrapping ErrDimensionMismatch if the bboxes do not have
+// the dimensions of the index, and stops building the tree when the context is done, see RBush.LoadContext.
+// In both cases the index is left unchanged
+func (r *RBushOf[C]) LoadContext(ctx context.Context, points InterfaceOf[C]) error {
+	coordinates, items, err := r.newItems(points)
+	if err != nil {
+		return err
+	}
+	return r.load(ctx, coordinates, items, false)
+}
+
+// InsertElement adds a single item to the index. p is expected to have length 1
+func (r *RBushOf[C]) InsertElement(p InterfaceOf[C]) error {
+	coordinates, items, err := r.newItems(p)
+	if err != nil {
+		return err
+	}
+	if len(items) != 1 {
+		return fmt.Errorf("rbush: expected a single item, got %v", len(items))
+	}
+	return r.insertItem(coordinates, items[0])
+}
+
+// Search returns the items intersecting the bbox, each of them is an InterfaceOf of length 1
+func (r *RBushOf[C]) Search(b BBoxOf[C]) []InterfaceOf[C] {
+	result := make([]InterfaceOf[C], 0)
+	r.SearchFunc(b, func(p InterfaceOf[C]) bool {
+		result = append(result, p)
+		return true
+	})
+	return result
+}
+
+// SearchFunc calls fn for every item intersecting the bbox until fn returns false. fn must not modify the index
+func (r *RBushOf[C]) SearchFunc(b BBoxOf[C], fn func(InterfaceOf[C]) bool) {
+	q := b.flat(r.dims)
+	if q == nil {
+		return
+	}
+	r.search(q, func(leaf *rnode[C, itemOf[C]], i int) bool {
+		return fn(leaf.values[i].value())
+	})
+}
+
+// Collides tells whether some item intersects the bbox
+func (r *RBushOf[C]) Collides(b BBoxOf[C]) bool {
+	q := b.flat(r.dims)
+	return q != nil && r.collides(q)
+}
+
+// CollidesAll tells for each bbox whether some item intersects it, as Collides would.
+// All the bboxes with the dimensions of the index are checked in a single walk of the tree
+func (r *RBushOf[C]) CollidesAll(boxes []BBoxOf[C]) []bool {
+	result := make([]bool, len(boxes))
+	flat := make([]C, 0, 2*r.dims*len(boxes))
+	valid := make([]int, 0, len(boxes))
+	for i, b := range boxes {
+		q := b.flat(r.dims)
+		if q == nil {
+			continue
+		}
+		flat = append(flat, q...)
+		valid = append(valid, i)
+	}
+	for k, collides := range r.collidesAll(flat) {
+		result[valid[k]] = collides
+	}
+	return result
+}
+
+// All returns every item in the index
+func (r *RBushOf[C]) All() []InterfaceOf[C] {
+	items := r.rootNode.flattenDownwards()
+	result := make([]InterfaceOf[C], len(items))
+	for i, it := range items {
+		result[i] = it.value()
+	}
+	return result
+}
+
+// Clear removes all items from the index
+func (r *RBushOf[C]) Clear() *RBushOf[C] {
+	if err := r.clear(); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// ToBBox returns the bbox of all the items in the index
+func (r *RBushOf[C]) ToBBox() BBoxOf[C] {
+	b := append([]C(nil), r.rootNode.bbox...)
+	return BBoxOf[C]{Min: b[:r.dims:r.dims], Max: b[r.dims:]}
+}
+
+// Knn returns the k items closest to the point, ordered by distance to their bbox. As in RBush.Knn,
+// k <= 0 returns all items, maxDistance <= 0 means no limit on distance and filter can be nil
+func (r *RBushOf[C]) Knn(point []C, k int, maxDistance float64, filter func(item InterfaceOf[C]) bool) []InterfaceOf[C] {
+	result := make([]InterfaceOf[C], 0)
+	if len(point) != r.dims {
+		return result
+	}
+	r.nearest(func(box []C) float64 {
+		return boxSqDistanceToPoint(box, point)
+	}, sqDistanceLimit(maxDistance), func(leaf *rnode[C, itemOf[C]], i int, _ float64) bool {
+		candidate := leaf.values[i].value()
+		if filter == nil || filter(candidate) {
+			result = append(result, candidate)
+		}
+		return k <= 0 || len(result) < k
+	})
+	return result
+}
+
+// RemoveElement removes the item from the index and reports whether it was found, see RBush.RemoveElement.
+// As InsertElement it returns an error wrapping ErrDimensionMismatch if the bbox does not have the dimensions of the index
+func (r *RBushOf[C]) RemoveElement(p ToBeRemovedOf[C]) (bool, error) {
+	min, max := p.GetBBox()
+	b := BBoxOf[C]{Min: min, Max: max}.flat(r.dims)
+	if b == nil {
+		return false, fmt.Errorf("%w: bbox has %v and %v coordinates, expected %v", ErrDimensionMismatch, len(min), len(max), r.dims)
+	}
+	return r.remove(b, func(it itemOf[C]) bool {
+		return p.IsContained(it.value())
+	})
+}
+
+// Check verifies the invariants of the index, see RBush.Check
+func (r *RBushOf[C]) Check() error {
+	return r.check(func(it itemOf[C]) bool {
+		return it.points != nil && it.index >= 0 && it.index < it.points.Len()
+	})
+}
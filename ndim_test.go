@@ -0,0 +1,383 @@
+package go_rbush
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// empty bbox, extending it with any other bbox results in the other bbox
+func newEmptyBBoxOf[C Coordinate](dims int) BBoxOf[C] {
+	lowest, highest := coordinateBounds[C]()
+	b := BBoxOf[C]{Min: make([]C, dims), Max: make([]C, dims)}
+	for d := 0; d < dims; d++ {
+		b.Min[d] = highest
+		b.Max[d] = lowest
+	}
+	return b
+}
+
+func (b BBoxOf[C]) equals(o BBoxOf[C]) bool {
+	for d := range b.Min {
+		if b.Min[d] != o.Min[d] || b.Max[d] != o.Max[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// squared distance from the point to the closest point of the box. 0 if the point is inside
+func (b BBoxOf[C]) sqDistanceToPoint(p []C) float64 {
+	sqDistance := 0.0
+	for d := range b.Min {
+		k := axisDistance(float64(p[d]), float64(b.Min[d]), float64(b.Max[d]))
+		sqDistance += k * k
+	}
+	return sqDistance
+}
+
+// each box holds its min coordinates followed by its max coordinates
+type hyperBoxes [][]float64
+
+func (h hyperBoxes) GetBBoxAt(i int) (min, max []float64) {
+	d := len(h[i]) / 2
+	return h[i][:d], h[i][d:]
+}
+
+func (h hyperBoxes) Len() int {
+	return len(h)
+}
+
+func (h hyperBoxes) Slice(i, j int) InterfaceN {
+	return h[i:j]
+}
+
+type hyperBoxToRemove []float64
+
+func (b hyperBoxToRemove) GetBBox() (min, max []float64) {
+	return hyperBoxes{b}.GetBBoxAt(0)
+}
+
+func (b hyperBoxToRemove) IsContained(points InterfaceN) bool {
+	bbox := hyperBoxes{b}.bbox(0)
+	for _, p := range points.(hyperBoxes) {
+		if bbox.equals(hyperBoxes{p}.bbox(0)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h hyperBoxes) bbox(i int) BBoxN {
+	min, max := h.GetBBoxAt(i)
+	return BBoxN{Min: min, Max: max}
+}
+
+func getHyperData(n, dims int, size float64) hyperBoxes {
+	data := make(hyperBoxes, n)
+	for i := range data {
+		data[i] = make([]float64, 2*dims)
+		for d := 0; d < dims; d++ {
+			data[i][d] = rand.Float64() * (100 - size)
+			data[i][dims+d] = data[i][d] + size*rand.Float64()
+		}
+	}
+	return data
+}
+
+// positions of the results in data, sorted
+func hyperPositions(data hyperBoxes, result []InterfaceN) []int {
+	positions := make([]int, len(result))
+	for i, r := range result {
+		positions[i] = -1
+		for j := range data {
+			if &data[j][0] == &r.(hyperBoxes)[0][0] {
+				positions[i] = j
+			}
+		}
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+func assertSearchMatchesBruteForce(t *testing.T, tree *RBushN, data hyperBoxes, present func(i int) bool) {
+	queries := getHyperData(50, tree.Dims(), 30)
+	boxes := make([]BBoxN, len(queries))
+	for k, q := range queries {
+		b := hyperBoxes{q}.bbox(0)
+		boxes[k] = b
+		expected := make([]int, 0)
+		for i := range data {
+			if present(i) && boxIntersects(b.flat(tree.Dims()), data.bbox(i).flat(tree.Dims())) {
+				expected = append(expected, i)
+			}
+		}
+		result := hyperPositions(data, tree.Search(b))
+		assertEqual(t, len(result), len(expected), "")
+		for i := range result {
+			assertEqual(t, result[i], expected[i], "")
+		}
+		assertEqual(t, tree.Collides(b), len(expected) > 0, "")
+	}
+	for k, collides := range tree.CollidesAll(boxes) {
+		assertEqual(t, collides, tree.Collides(boxes[k]), "")
+	}
+}
+
+func TestRBushN_Load(t *testing.T) {
+	for dims := 1; dims <= 4; dims++ {
+		data := getHyperData(5000, dims, 5)
+		tree := NewNWithOptions(dims, Options{MAX_ENTRIES: 9}).Load(data)
+		assertNoError(t, tree.Check())
+		assertEqual(t, len(tree.All()), len(data), "")
+		assertSearchMatchesBruteForce(t, tree, data, func(int) bool { return true })
+	}
+}
+
+func TestNewOf_RejectsInvalidDimsAndOptions(t *testing.T) {
+	for _, dims := range []int{0, -1} {
+		tree, err := NewOfWithValidOptions[int32](dims, Options{MAX_ENTRIES: 9})
+		assertEqual(t, errors.Is(err, ErrInvalidOptions), true, "")
+		assertEqual(t, tree, (*RBushOf[int32])(nil), "")
+	}
+	_, err := NewOfWithValidOptions[float64](2, Options{MAX_ENTRIES: 3})
+	assertEqual(t, errors.Is(err, ErrInvalidOptions), true, "")
+	tree, err := NewOfWithValidOptions[float64](2, Options{MAX_ENTRIES: 9})
+	assertNoError(t, err)
+	assertEqual(t, tree.Dims(), 2, "")
+
+	defer func() {
+		err, ok := recover().(error)
+		assertEqual(t, ok, true, "NewOf should panic with an error")
+		assertEqual(t, errors.Is(err, ErrInvalidOptions), true, "")
+	}()
+	NewN(0)
+}
+
+func TestRBushN_LoadDoesNotReorder(t *testing.T) {
+	data := getHyperData(1000, 3, 5)
+	first := data[0]
+	NewN(3).Load(data)
+	assertEqual(t, &data[0][0], &first[0], "")
+}
+
+func TestRBushN_InsertAndRemove(t *testing.T) {
+	data := getHyperData(2000, 3, 5)
+	tree := NewNWithOptions(3, Options{MAX_ENTRIES: 6})
+	for i := range data {
+		assertNoError(t, tree.InsertElement(data[i:i+1]))
+	}
+	assertNoError(t, tree.Check())
+	assertSearchMatchesBruteForce(t, tree, data, func(int) bool { return true })
+
+	for i := 0; i < len(data); i += 2 {
+		found, err := tree.RemoveElement(hyperBoxToRemove(data[i]))
+		assertNoError(t, err)
+		assertEqual(t, found, true, "")
+	}
+	found, err := tree.RemoveElement(hyperBoxToRemove(data[0]))
+	assertNoError(t, err)
+	assertEqual(t, found, false, "")
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), len(data)/2, "")
+	assertSearchMatchesBruteForce(t, tree, data, func(i int) bool { return i%2 == 1 })
+
+	for i := 1; i < len(data); i += 2 {
+		tree.RemoveElement(hyperBoxToRemove(data[i]))
+	}
+	assertNoError(t, tree.Check())
+	assertEqual(t, tree.rootNode.height, 1, "")
+	assertEqual(t, len(tree.All()), 0, "")
+}
+
+func TestRBushN_LoadIntoExistingTree(t *testing.T) {
+	data := getHyperData(3000, 3, 5)
+	tree := NewNWithOptions(3, Options{MAX_ENTRIES: 4}).Load(data[:100]).Load(data[100:2000]).Load(data[2000:2002]).Load(data[2002:])
+	assertNoError(t, tree.Check())
+	assertSearchMatchesBruteForce(t, tree, data, func(int) bool { return true })
+}
+
+func TestRBushN_Knn(t *testing.T) {
+	data := getHyperData(3000, 3, 1)
+	tree := NewN(3).Load(data)
+	for _, p := range getHyperData(20, 3, 0) {
+		point := p[:3]
+		result := tree.Knn(point, 10, 0, nil)
+		distances := make([]float64, len(data))
+		for i := range data {
+			distances[i] = data.bbox(i).sqDistanceToPoint(point)
+		}
+		sort.Float64s(distances)
+		assertEqual(t, len(result), 10, "")
+		for i, r := range result {
+			assertEqual(t, r.(hyperBoxes).bbox(0).sqDistanceToPoint(point), distances[i], "")
+		}
+	}
+	assertEqual(t, len(tree.Knn([]float64{50, 50, 50}, 0, 0, nil)), len(data), "")
+	assertEqual(t, len(tree.Knn([]float64{50, 50}, 0, 0, nil)), 0, "")
+}
+
+func TestRBushN_DimensionMismatch(t *testing.T) {
+	tree := NewN(3)
+	err := tree.InsertElement(hyperBoxes{{0, 0, 1, 1}})
+	assertEqual(t, errors.Is(err, ErrDimensionMismatch), true, "")
+	err = tree.LoadContext(context.Background(), getHyperData(100, 2, 1))
+	assertEqual(t, errors.Is(err, ErrDimensionMismatch), true, "")
+	assertEqual(t, len(tree.All()), 0, "")
+	_, err = tree.RemoveElement(hyperBoxToRemove([]float64{0, 0, 1, 1}))
+	assertEqual(t, errors.Is(err, ErrDimensionMismatch), true, "")
+	tree.Load(getHyperData(100, 3, 1))
+	collides := tree.CollidesAll([]BBoxN{{Min: []float64{0, 0}, Max: []float64{100, 100}}, {Min: []float64{0, 0, 0}, Max: []float64{100, 100, 100}}})
+	assertEqual(t, collides[0], false, "Boxes with other dimensions do not collide")
+	assertEqual(t, collides[1], true, "")
+	tree.Clear()
+	defer func() {
+		err, ok := recover().(error)
+		assertEqual(t, ok, true, "Load should panic with an error")
+		assertEqual(t, errors.Is(err, ErrDimensionMismatch), true, "")
+		assertEqual(t, len(tree.All()), 0, "")
+	}()
+	tree.Load(getHyperData(100, 2, 1))
+}
+
+func TestRBushN_TwoDimensionsMatchesRBush(t *testing.T) {
+	data := getData(2000, 1)
+	hyper := make(hyperBoxes, len(data))
+	for i, d := range data {
+		hyper[i] = []float64{d[0], d[1], d[2], d[3]}
+	}
+	tree := NewN(2).Load(hyper)
+	rbush := New().Load(data)
+	for _, q := range getData(50, 10) {
+		assertEqual(t, len(tree.Search(BBoxN{Min: q[:2], Max: q[2:]})), len(rbush.Search(BBox{q[0], q[1], q[2], q[3]})), "")
+	}
+	assertEqual(t, tree.ToBBox().equals(BBoxN{Min: []float64{rbush.ToBBox().MinX, rbush.ToBBox().MinY}, Max: []float64{rbush.ToBBox().MaxX, rbush.ToBBox().MaxY}}), true, "")
+}
+
+// boxes with coordinates of any type, min coordinates followed by max coordinates
+type boxesOf[C Coordinate] [][]C
+
+func (h boxesOf[C]) GetBBoxAt(i int) (min, max []C) {
+	d := len(h[i]) / 2
+	return h[i][:d], h[i][d:]
+}
+
+func (h boxesOf[C]) Len() int {
+	return len(h)
+}
+
+func (h boxesOf[C]) Slice(i, j int) InterfaceOf[C] {
+	return h[i:j]
+}
+
+func (h boxesOf[C]) bbox(i int) BBoxOf[C] {
+	min, max := h.GetBBoxAt(i)
+	return BBoxOf[C]{Min: min, Max: max}
+}
+
+type boxOfToRemove[C Coordinate] []C
+
+func (b boxOfToRemove[C]) GetBBox() (min, max []C) {
+	return boxesOf[C]{b}.GetBBoxAt(0)
+}
+
+func (b boxOfToRemove[C]) IsContained(points InterfaceOf[C]) bool {
+	return &points.(boxesOf[C])[0][0] == &b[0]
+}
+
+func TestRBushOf_Int32IntersectionIsExact(t *testing.T) {
+	// float32 cannot tell apart consecutive integers this big
+	const offset = 1 << 30
+	data := make(boxesOf[int32], 0)
+	for x := int32(0); x < 100; x++ {
+		for y := int32(0); y < 100; y++ {
+			data = append(data, []int32{offset + x, offset + y, offset + x, offset + y})
+		}
+	}
+	tree := NewOf[int32](2, Options{MAX_ENTRIES: 9}).Load(data)
+	assertNoError(t, tree.Check())
+	assertEqual(t, tree.ToBBox().equals(BBoxOf[int32]{Min: []int32{offset, offset}, Max: []int32{offset + 99, offset + 99}}), true, "")
+	for x := int32(0); x < 100; x += 7 {
+		for y := int32(0); y < 100; y += 3 {
+			result := tree.Search(BBoxOf[int32]{Min: []int32{offset + x, offset + y}, Max: []int32{offset + x + 1, offset + y}})
+			expected := 2
+			if x == 99 {
+				expected = 1
+			}
+			assertEqual(t, len(result), expected, "")
+			for _, r := range result {
+				min, _ := r.GetBBoxAt(0)
+				assertEqual(t, min[1], offset+y, "")
+			}
+		}
+	}
+	assertEqual(t, tree.Collides(BBoxOf[int32]{Min: []int32{offset + 100, offset}, Max: []int32{offset + 100, offset + 100}}), false, "")
+	assertEqual(t, tree.Collides(BBoxOf[int32]{Min: []int32{offset - 1, offset}, Max: []int32{offset - 1, offset + 100}}), false, "")
+
+	nearest := tree.Knn([]int32{offset + 50, offset + 50}, 1, 0, nil)
+	assertEqual(t, nearest[0].(boxesOf[int32]).bbox(0).equals(data.bbox(50*100+50)), true, "")
+}
+
+func TestRBushOf_Int32InsertAndRemove(t *testing.T) {
+	data := make(boxesOf[int32], 2000)
+	for i := range data {
+		x, y := rand.Int31n(1000), rand.Int31n(1000)
+		data[i] = []int32{x, y, x + rand.Int31n(10), y + rand.Int31n(10)}
+	}
+	tree := NewOf[int32](2, Options{MAX_ENTRIES: 4})
+	for i := range data {
+		assertNoError(t, tree.InsertElement(data[i:i+1]))
+	}
+	assertNoError(t, tree.Check())
+	for i := 0; i < len(data); i += 2 {
+		found, err := tree.RemoveElement(boxOfToRemove[int32](data[i]))
+		assertNoError(t, err)
+		assertEqual(t, found, true, "")
+	}
+	assertNoError(t, tree.Check())
+	for _, q := range data[:100] {
+		b := boxesOf[int32]{q}.bbox(0)
+		expected := 0
+		for i := 1; i < len(data); i += 2 {
+			if boxIntersects(b.flat(tree.Dims()), data.bbox(i).flat(tree.Dims())) {
+				expected++
+			}
+		}
+		assertEqual(t, len(tree.Search(b)), expected, "")
+	}
+
+	for i := 1; i < len(data); i += 2 {
+		tree.RemoveElement(boxOfToRemove[int32](data[i]))
+	}
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), 0, "")
+	assertEqual(t, tree.ToBBox().equals(newEmptyBBoxOf[int32](2)), true, "")
+}
+
+func TestRBushOf_Float32MatchesFloat64(t *testing.T) {
+	data := getHyperData(3000, 3, 5)
+	data32 := make(boxesOf[float32], len(data))
+	for i, d := range data {
+		// values representable in float32 so that both indexes hold the same boxes
+		data[i] = make([]float64, len(d))
+		data32[i] = make([]float32, len(d))
+		for j := range d {
+			data32[i][j] = float32(d[j])
+			data[i][j] = float64(data32[i][j])
+		}
+	}
+	tree := NewN(3).Load(data)
+	tree32 := NewOf[float32](3, Options{MAX_ENTRIES: 9}).Load(data32)
+	assertNoError(t, tree32.Check())
+	for _, q := range getHyperData(50, 3, 30) {
+		q32 := make([]float32, len(q))
+		for j := range q {
+			q32[j] = float32(q[j])
+			q[j] = float64(q32[j])
+		}
+		assertEqual(t, len(tree32.Search(boxesOf[float32]{q32}.bbox(0))), len(tree.Search(hyperBoxes{q}.bbox(0))), "")
+		assertEqual(t, len(tree32.Knn(q32[:3], 5, 10, nil)), len(tree.Knn(q[:3], 5, 10, nil)), "")
+	}
+}
@@ -0,0 +1,59 @@
+package go_rbush
+
+import "errors"
+
+// ErrReadOnly is returned when modifying a snapshot
+var ErrReadOnly = errors.New("rbush: snapshots are read only")
+
+// Snapshot returns a read only copy of the index that is not affected by later modifications of r.
+// Both trees share all their nodes, they are only copied when r modifies them, so taking a snapshot is O(1).
+// Readers can keep querying a snapshot while writers keep mutating the original index.
+// Modifications of the snapshot return ErrReadOnly, methods that return the index for chaining panic with it
+func (r *RBush) Snapshot() *RBush {
+	return &RBush{rtree: r.snapshot()}
+}
+
+// read only copy sharing all the nodes
+func (t *rtree[C, E]) snapshot() rtree[C, E] {
+	// t does not own the current nodes anymore
+	t.cow = &copyOnWrite{}
+	return rtree[C, E]{
+		dims:     t.dims,
+		options:  t.options,
+		rootNode: t.rootNode,
+		cow:      &copyOnWrite{},
+		readOnly: true,
+	}
+}
+
+func (t *rtree[C, E]) checkWritable() error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// return a node that can be modified by the tree, copying n if it is shared with a snapshot
+func (t *rtree[C, E]) mutable(n *rnode[C, E]) *rnode[C, E] {
+	if n.cow == t.cow {
+		return n
+	}
+	c := *n
+	c.cow = t.cow
+	c.bbox = append([]C(nil), n.bbox...)
+	if n.values != nil {
+		c.coordinates = append(make([]C, 0, len(n.coordinates)+len(n.bbox)), n.coordinates...)
+		c.values = append(make([]E, 0, len(n.values)+1), n.values...)
+	}
+	if n.children != nil {
+		c.children = append(make([]*rnode[C, E], 0, len(n.children)+1), n.children...)
+	}
+	return &c
+}
+
+// make the i-th child of the (mutable) node n mutable and return it
+func (t *rtree[C, E]) mutableChild(n *rnode[C, E], i int) *rnode[C, E] {
+	c := t.mutable(n.children[i])
+	n.children[i] = c
+	return c
+}
@@ -0,0 +1,135 @@
+package go_rbush
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestRBush_SnapshotIsNotAffectedByModifications(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	snapshot := tree.Snapshot()
+	expected := getTreePointsAsCoordinates(snapshot.rootNode)
+
+	for i := 0; i < len(data)/2; i++ {
+		removeElement(t, tree, bboxToRemove(data[i]))
+	}
+	for i := 0; i < 20; i++ {
+		assertNoError(t, tree.InsertElement(bboxes{{float64(i), 100, float64(i), 100}}))
+	}
+	tree.Load(getSomeDataBBoxes(100))
+	_, err := tree.Update(bboxToRemove(data[len(data)-1]), bboxes{{-1, -1, -1, -1}})
+	assertNoError(t, err)
+
+	assertNoError(t, tree.Check())
+	assertNoError(t, snapshot.Check())
+	assertEqual(t, len(tree.All()), len(data)-len(data)/2+20+100, "")
+	result := getTreePointsAsCoordinates(snapshot.rootNode)
+	assertEqual(t, len(result), len(expected), "")
+	for i := range result {
+		assertEqual(t, result[i], expected[i], "")
+	}
+	assertEqual(t, len(snapshot.SearchItems(BBox{-1, -1, -1, -1})), 0, "")
+}
+
+func TestRBush_SnapshotIsReadOnly(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	snapshot := tree.Snapshot()
+	assertEqual(t, errors.Is(snapshot.InsertElement(bboxes{{200, 200, 200, 200}}), ErrReadOnly), true, "")
+	removed, err := snapshot.RemoveElement(bboxToRemove{0, 0, 0, 0})
+	assertEqual(t, removed, false, "")
+	assertEqual(t, errors.Is(err, ErrReadOnly), true, "")
+	_, err = snapshot.Update(bboxToRemove{0, 0, 0, 0}, bboxes{{1, 1, 1, 1}})
+	assertEqual(t, errors.Is(err, ErrReadOnly), true, "")
+	_, err = snapshot.UpdateAll([]Move{{Old: bboxToRemove{0, 0, 0, 0}, New: bboxes{{1, 1, 1, 1}}}})
+	assertEqual(t, errors.Is(err, ErrReadOnly), true, "")
+	assertEqual(t, errors.Is(snapshot.LoadContext(context.Background(), getSomeDataBBoxes(10)), ErrReadOnly), true, "")
+	assertPanicsWith(t, ErrReadOnly, func() { snapshot.Load(getSomeDataBBoxes(10)) })
+	assertPanicsWith(t, ErrReadOnly, func() { snapshot.Clear() })
+	assertPanicsWith(t, ErrReadOnly, func() { snapshot.Remove(bboxToRemove{0, 0, 0, 0}) })
+
+	// neither the snapshot nor the original were modified
+	assertNoError(t, tree.Check())
+	assertNoError(t, snapshot.Check())
+	assertEqual(t, snapshot.rootNode, tree.rootNode, "")
+	assertEqual(t, len(snapshot.All()), len(data), "")
+	assertEqual(t, snapshot.Collides(BBox{0, 0, 0, 0}), true, "")
+	assertEqual(t, snapshot.Collides(BBox{200, 200, 200, 200}), false, "")
+
+	// the original can still be modified
+	assertNoError(t, tree.InsertElement(bboxes{{200, 200, 200, 200}}))
+	assertEqual(t, snapshot.Collides(BBox{200, 200, 200, 200}), false, "")
+}
+
+func assertPanicsWith(t *testing.T, expected error, fn func()) {
+	t.Helper()
+	defer func() {
+		err, ok := recover().(error)
+		assertEqual(t, ok && errors.Is(err, expected), true, "Should panic with "+expected.Error())
+	}()
+	fn()
+}
+
+func TestRBush_SnapshotSharesUnchangedSubtrees(t *testing.T) {
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(getSomeDataBBoxes(1000))
+	snapshot := tree.Snapshot()
+	removeElement(t, tree, bboxToRemove{500, 500, 500, 500})
+
+	snapshotNodes := map[*rbushNode]bool{}
+	for _, n := range allNodes(snapshot.rootNode) {
+		snapshotNodes[n] = true
+	}
+	copied := 0
+	for _, n := range allNodes(tree.rootNode) {
+		if !snapshotNodes[n] {
+			copied++
+		}
+	}
+	// only the path to the removed item is copied
+	assertEqual(t, copied, tree.rootNode.height, "")
+}
+
+func allNodes(n *rbushNode) []*rbushNode {
+	nodes := []*rbushNode{n}
+	for _, c := range n.children {
+		nodes = append(nodes, allNodes(c)...)
+	}
+	return nodes
+}
+
+func TestRBush_SnapshotsOfSnapshots(t *testing.T) {
+	data := make(bboxes, 500)
+	for i := range data {
+		x, y := rand.Float64()*100, rand.Float64()*100
+		data[i] = [4]float64{x, y, x + rand.Float64(), y + rand.Float64()}
+	}
+	tree := New()
+	snapshots := []*RBush{}
+	sizes := []int{}
+	for i := range data {
+		assertNoError(t, tree.InsertElement(data[i:i+1]))
+		if i%50 == 0 {
+			snapshots = append(snapshots, tree.Snapshot())
+			sizes = append(sizes, i+1)
+		}
+	}
+	for i := 0; i < len(data); i += 2 {
+		removeElement(t, tree, bboxToRemove(data[i]))
+	}
+	for i, s := range snapshots {
+		assertNoError(t, s.Check())
+		result := getTreePointsAsCoordinates(s.rootNode)
+		expected := append(bboxes{}, data[:sizes[i]]...)
+		sort.Sort(expected)
+		assertEqual(t, len(result), len(expected), "")
+		for j := range result {
+			assertEqual(t, result[j], expected[j], "")
+		}
+	}
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), len(data)/2, "")
+}
@@ -0,0 +1,268 @@
+package go_rbush
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// StaticIndex is a packed, read only, index following flatbush. Items are sorted by the hilbert value of their center
+// and packed into nodes of MAX_ENTRIES, so the tree is fully filled and stored in flat arrays:
+// boxes holds the bboxes of the items followed by the ones of each level of nodes up to the root.
+// For items indices holds their position in the collection, for nodes the position in boxes of their first child
+type StaticIndex struct {
+	points      Interface
+	nodeSize    int
+	numItems    int
+	levelBounds []int // end of each level in boxes
+	boxes       []BBox
+	indices     []int
+}
+
+const defaultStaticNodeSize = 16
+
+// NewStatic builds a packed index of the points with nodes of 16 entries. points is not modified
+func NewStatic(points Interface) *StaticIndex {
+	return NewStaticWithOptions(points, Options{MAX_ENTRIES: defaultStaticNodeSize})
+}
+
+// NewStaticWithOptions builds a packed index of the points, MAX_ENTRIES is the size of the nodes
+func NewStaticWithOptions(points Interface, options Options) *StaticIndex {
+	nodeSize := max(2, options.MAX_ENTRIES)
+	n := points.Len()
+	s := &StaticIndex{points: points, nodeSize: nodeSize, numItems: n}
+	if n == 0 {
+		return s
+	}
+
+	numNodes := n
+	s.levelBounds = []int{n}
+	for levelSize := n; ; {
+		levelSize = (levelSize + nodeSize - 1) / nodeSize
+		numNodes += levelSize
+		s.levelBounds = append(s.levelBounds, numNodes)
+		if levelSize == 1 {
+			break
+		}
+	}
+
+	s.boxes = make([]BBox, n, numNodes)
+	s.indices = make([]int, n, numNodes)
+	extent := BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for i := 0; i < n; i++ {
+		x1, y1, x2, y2 := points.GetBBoxAt(i)
+		s.boxes[i] = BBox{x1, y1, x2, y2}
+		s.indices[i] = i
+		extent = extent.extend(s.boxes[i])
+	}
+
+	hilbertValues := make([]uint32, n)
+	width, height := extent.MaxX-extent.MinX, extent.MaxY-extent.MinY
+	for i, b := range s.boxes {
+		hilbertValues[i] = hilbert(hilbertCoordinate(b.MinX+b.MaxX, 2*extent.MinX, width), hilbertCoordinate(b.MinY+b.MaxY, 2*extent.MinY, height))
+	}
+	sort.Sort(hilbertSorter{values: hilbertValues, boxes: s.boxes, indices: s.indices})
+
+	// pack each level into the next one
+	pos := 0
+	for _, end := range s.levelBounds[:len(s.levelBounds)-1] {
+		for pos < end {
+			nodeIndex := pos
+			nodeBBox := s.boxes[pos]
+			for j := 0; j < nodeSize && pos < end; j++ {
+				nodeBBox = nodeBBox.extend(s.boxes[pos])
+				pos++
+			}
+			s.boxes = append(s.boxes, nodeBBox)
+			s.indices = append(s.indices, nodeIndex)
+		}
+	}
+	return s
+}
+
+// position of the center of a box, given as the sum of both extremes, in a grid of 2^16 cells
+func hilbertCoordinate(doubleCenter, doubleMin, size float64) uint32 {
+	if size == 0 {
+		return 0
+	}
+	return uint32(math.Floor(0xFFFF * (doubleCenter - doubleMin) / 2 / size))
+}
+
+// Len returns the number of items in the index
+func (s *StaticIndex) Len() int {
+	return s.numItems
+}
+
+// ToBBox returns the bbox of all the items in the index
+func (s *StaticIndex) ToBBox() BBox {
+	if s.numItems == 0 {
+		return BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	}
+	return s.boxes[len(s.boxes)-1]
+}
+
+// Search returns the positions in the collection of the items intersecting the bbox
+func (s *StaticIndex) Search(b BBox) []int {
+	result := make([]int, 0)
+	s.SearchFunc(b, func(index int) bool {
+		result = append(result, index)
+		return true
+	})
+	return result
+}
+
+// SearchItems returns the items intersecting the bbox. Each item is an Interface of length 1
+func (s *StaticIndex) SearchItems(b BBox) []Interface {
+	result := make([]Interface, 0)
+	s.SearchFunc(b, func(index int) bool {
+		result = append(result, s.points.Slice(index, index+1))
+		return true
+	})
+	return result
+}
+
+// SearchFunc calls fn with the position in the collection of every item intersecting the bbox until fn returns false
+func (s *StaticIndex) SearchFunc(b BBox, fn func(index int) bool) {
+	if s.numItems == 0 {
+		return
+	}
+	// positions in boxes of the first child of the nodes left to visit
+	stack := make([]int, 0, 16)
+	nodeIndex := len(s.boxes) - 1
+	for {
+		end := min(nodeIndex+s.nodeSize, s.levelEnd(nodeIndex))
+		for pos := nodeIndex; pos < end; pos++ {
+			if !b.intersects(s.boxes[pos]) {
+				continue
+			}
+			if nodeIndex >= s.numItems {
+				stack = append(stack, s.indices[pos])
+			} else if !fn(s.indices[pos]) {
+				return
+			}
+		}
+		if len(stack) == 0 {
+			return
+		}
+		nodeIndex, stack = stack[len(stack)-1], stack[:len(stack)-1]
+	}
+}
+
+// Collides tells whether some item intersects the bbox
+func (s *StaticIndex) Collides(b BBox) bool {
+	collides := false
+	s.SearchFunc(b, func(int) bool {
+		collides = true
+		return false
+	})
+	return collides
+}
+
+// Knn returns the positions in the collection of the k items closest to the point (x, y), ordered by distance.
+// As in RBush.Knn, k <= 0 returns all items, maxDistance <= 0 means no limit on distance and filter can be nil
+func (s *StaticIndex) Knn(x, y float64, k int, maxDistance float64, filter func(index int) bool) []int {
+	result := make([]int, 0)
+	if s.numItems == 0 {
+		return result
+	}
+	maxSqDistance := sqDistanceLimit(maxDistance)
+	queue := make(knnQueue[int], 0)
+	nodeIndex := len(s.boxes) - 1
+	for {
+		end := min(nodeIndex+s.nodeSize, s.levelEnd(nodeIndex))
+		for pos := nodeIndex; pos < end; pos++ {
+			sqDistance := s.boxes[pos].sqDistanceToPoint(x, y)
+			if sqDistance <= maxSqDistance {
+				heap.Push(&queue, knnElement[int]{value: s.indices[pos], isItem: nodeIndex < s.numItems, sqDistance: sqDistance})
+			}
+		}
+		// items at the top of the queue are closer than any node left to visit
+		for len(queue) != 0 && queue[0].isItem {
+			candidate := heap.Pop(&queue).(knnElement[int]).value
+			if filter == nil || filter(candidate) {
+				result = append(result, candidate)
+			}
+			if k > 0 && len(result) == k {
+				return result
+			}
+		}
+		if len(queue) == 0 {
+			return result
+		}
+		nodeIndex = heap.Pop(&queue).(knnElement[int]).value
+	}
+}
+
+// end in boxes of the level of the node
+func (s *StaticIndex) levelEnd(nodeIndex int) int {
+	i := sort.SearchInts(s.levelBounds, nodeIndex+1)
+	return s.levelBounds[i]
+}
+
+type hilbertSorter struct {
+	values  []uint32
+	boxes   []BBox
+	indices []int
+}
+
+func (h hilbertSorter) Len() int {
+	return len(h.values)
+}
+
+func (h hilbertSorter) Less(i, j int) bool {
+	return h.values[i] < h.values[j]
+}
+
+func (h hilbertSorter) Swap(i, j int) {
+	h.values[i], h.values[j] = h.values[j], h.values[i]
+	h.boxes[i], h.boxes[j] = h.boxes[j], h.boxes[i]
+	h.indices[i], h.indices[j] = h.indices[j], h.indices[i]
+}
+
+// hilbert value of a point in a 2^16 x 2^16 grid. Fast Hilbert curve algorithm by http://threadlocalmutex.com/
+// ported from flatbush, which ported it from C++ https://github.com/rawrunprotected/hilbert_curves (public domain)
+func hilbert(x, y uint32) uint32 {
+	a := x ^ y
+	b := 0xFFFF ^ a
+	c := 0xFFFF ^ (x | y)
+	d := x & (y ^ 0xFFFF)
+
+	A := a | (b >> 1)
+	B := (a >> 1) ^ a
+	C := ((c >> 1) ^ (b & (d >> 1))) ^ c
+	D := ((a & (c >> 1)) ^ (d >> 1)) ^ d
+
+	a, b, c, d = A, B, C, D
+	A = (a & (a >> 2)) ^ (b & (b >> 2))
+	B = (a & (b >> 2)) ^ (b & ((a ^ b) >> 2))
+	C ^= (a & (c >> 2)) ^ (b & (d >> 2))
+	D ^= (b & (c >> 2)) ^ ((a ^ b) & (d >> 2))
+
+	a, b, c, d = A, B, C, D
+	A = (a & (a >> 4)) ^ (b & (b >> 4))
+	B = (a & (b >> 4)) ^ (b & ((a ^ b) >> 4))
+	C ^= (a & (c >> 4)) ^ (b & (d >> 4))
+	D ^= (b & (c >> 4)) ^ ((a ^ b) & (d >> 4))
+
+	a, b, c, d = A, B, C, D
+	C ^= (a & (c >> 8)) ^ (b & (d >> 8))
+	D ^= (b & (c >> 8)) ^ ((a ^ b) & (d >> 8))
+
+	a = C ^ (C >> 1)
+	b = D ^ (D >> 1)
+
+	i0 := x ^ y
+	i1 := b | (0xFFFF ^ (i0 | a))
+
+	i0 = (i0 | (i0 << 8)) & 0x00FF00FF
+	i0 = (i0 | (i0 << 4)) & 0x0F0F0F0F
+	i0 = (i0 | (i0 << 2)) & 0x33333333
+	i0 = (i0 | (i0 << 1)) & 0x55555555
+
+	i1 = (i1 | (i1 << 8)) & 0x00FF00FF
+	i1 = (i1 | (i1 << 4)) & 0x0F0F0F0F
+	i1 = (i1 | (i1 << 2)) & 0x33333333
+	i1 = (i1 | (i1 << 1)) & 0x55555555
+
+	return (i1 << 1) | i0
+}
@@ -0,0 +1,87 @@
+package go_rbush
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestStaticIndex_SearchMatchesBruteForce(t *testing.T) {
+	data := getData(10000, 1)
+	for _, nodeSize := range []int{4, 16} {
+		index := NewStaticWithOptions(data, Options{MAX_ENTRIES: nodeSize})
+		assertEqual(t, index.Len(), len(data), "")
+		for _, q := range getData(100, 10) {
+			b := BBox{q[0], q[1], q[2], q[3]}
+			expected := make([]int, 0)
+			for i, d := range data {
+				if b.intersects(BBox{d[0], d[1], d[2], d[3]}) {
+					expected = append(expected, i)
+				}
+			}
+			result := index.Search(b)
+			sort.Ints(result)
+			assertEqual(t, len(result), len(expected), "")
+			for i := range result {
+				assertEqual(t, result[i], expected[i], "")
+			}
+			assertEqual(t, index.Collides(b), len(expected) > 0, "")
+		}
+	}
+}
+
+func TestStaticIndex_DoesNotModifyPoints(t *testing.T) {
+	data := getDataExample()
+	index := NewStaticWithOptions(data, Options{MAX_ENTRIES: 4})
+	assertEqual(t, data[1], [4]float64{10, 10, 10, 10}, "")
+	assertEqual(t, index.ToBBox(), BBox{0, 0, 95, 95}, "")
+	items := index.SearchItems(BBox{10, 10, 10, 10})
+	assertEqual(t, len(items), 1, "")
+	assertEqual(t, items[0].(bboxes)[0], [4]float64{10, 10, 10, 10}, "")
+	assertEqual(t, index.Search(BBox{10, 10, 10, 10})[0], 1, "")
+}
+
+func TestStaticIndex_Empty(t *testing.T) {
+	for _, index := range []*StaticIndex{NewStatic(bboxes{}), NewStatic(bboxes{{1, 1, 2, 2}})} {
+		b := BBox{0, 0, 10, 10}
+		assertEqual(t, len(index.Search(b)), index.Len(), "")
+		assertEqual(t, index.Collides(b), index.Len() == 1, "")
+		assertEqual(t, len(index.Knn(0, 0, 0, 0, nil)), index.Len(), "")
+	}
+	assertEqual(t, NewStatic(bboxes{}).ToBBox().MinX, math.Inf(1), "")
+}
+
+func TestStaticIndex_SearchFuncStops(t *testing.T) {
+	index := NewStaticWithOptions(getDataExample(), Options{MAX_ENTRIES: 4})
+	visited := 0
+	index.SearchFunc(BBox{0, 0, 100, 100}, func(int) bool {
+		visited++
+		return visited < 3
+	})
+	assertEqual(t, visited, 3, "")
+}
+
+func TestStaticIndex_Knn(t *testing.T) {
+	data := getData(5000, 1)
+	index := NewStaticWithOptions(data, Options{MAX_ENTRIES: 8})
+	tree := NewWithOptions(Options{MAX_ENTRIES: 8}).Load(append(bboxes{}, data...))
+	for _, p := range getData(20, 0) {
+		result := index.Knn(p[0], p[1], 10, 0, nil)
+		expected := tree.Knn(p[0], p[1], 10, 0, nil)
+		assertEqual(t, len(result), 10, "")
+		for i := range result {
+			d := data[result[i]]
+			e := expected[i].(bboxes)[0]
+			assertEqual(t, BBox{d[0], d[1], d[2], d[3]}.sqDistanceToPoint(p[0], p[1]), BBox{e[0], e[1], e[2], e[3]}.sqDistanceToPoint(p[0], p[1]), "")
+		}
+	}
+	even := index.Knn(50, 50, 0, 5, func(i int) bool {
+		return i%2 == 0
+	})
+	for _, i := range even {
+		d := data[i]
+		assertEqual(t, i%2, 0, "")
+		assertEqual(t, BBox{d[0], d[1], d[2], d[3]}.sqDistanceToPoint(50, 50) <= 25, true, "")
+	}
+	assertEqual(t, len(index.Knn(50, 50, 0, 0, nil)), len(data), "")
+}
@@ -0,0 +1,271 @@
+package go_rbush
+
+import (
+	"context"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+// SyncRBush is an RBush safe for concurrent use. Queries share a read lock, modifications take the write lock
+type SyncRBush struct {
+	mu    sync.RWMutex
+	rbush *RBush
+}
+
+func NewSync() *SyncRBush {
+	return Synchronized(New())
+}
+
+func NewSyncWithOptions(options Options) *SyncRBush {
+	return Synchronized(NewWithOptions(options))
+}
+
+// Synchronized wraps an existing index. The index should not be used directly afterwards
+func Synchronized(r *RBush) *SyncRBush {
+	return &SyncRBush{rbush: r}
+}
+
+func (s *SyncRBush) Load(points Interface) *SyncRBush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rbush.Load(points)
+	return s
+}
+
+func (s *SyncRBush) LoadSortedArray(points Interface) *SyncRBush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rbush.LoadSortedArray(points)
+	return s
+}
+
+// LoadContext holds the write lock until the tree is built or the context is done
+func (s *SyncRBush) LoadContext(ctx context.Context, points Interface) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.LoadContext(ctx, points)
+}
+
+// LoadSortedArrayContext holds the write lock until the tree is built or the context is done
+func (s *SyncRBush) LoadSortedArrayContext(ctx context.Context, points Interface) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.LoadSortedArrayContext(ctx, points)
+}
+
+// LoadCSV reads the features before taking the write lock, so queries are only blocked while the tree is built
+func (s *SyncRBush) LoadCSV(reader io.Reader, columns CSVColumns) (Features, error) {
+	features, err := ReadCSV(reader, columns)
+	if err != nil {
+		return nil, err
+	}
+	return features, s.LoadContext(context.Background(), features)
+}
+
+// LoadGeoJSONSeq reads the features before taking the write lock, see LoadCSV
+func (s *SyncRBush) LoadGeoJSONSeq(reader io.Reader) (Features, error) {
+	features, err := ReadGeoJSONSeq(reader)
+	if err != nil {
+		return nil, err
+	}
+	return features, s.LoadContext(context.Background(), features)
+}
+
+func (s *SyncRBush) InsertElement(p Interface) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.InsertElement(p)
+}
+
+func (s *SyncRBush) Remove(p ToBeRemoved) *SyncRBush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rbush.Remove(p)
+	return s
+}
+
+func (s *SyncRBush) RemoveElement(p ToBeRemoved) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.RemoveElement(p)
+}
+
+func (s *SyncRBush) Update(old ToBeRemoved, p Interface) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.Update(old, p)
+}
+
+func (s *SyncRBush) UpdateAll(moves []Move) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.UpdateAll(moves)
+}
+
+func (s *SyncRBush) Clear() *SyncRBush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rbush.Clear()
+	return s
+}
+
+// Snapshot returns a read only copy of the index that is not affected by later modifications.
+// The snapshot can be searched concurrently without locking
+func (s *SyncRBush) Snapshot() *RBush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.Snapshot()
+}
+
+func (s *SyncRBush) FromJSON(data []byte, codec ItemCodec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rbush.FromJSON(data, codec)
+}
+
+func (s *SyncRBush) Search(b BBox) []*Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.Search(b)
+}
+
+func (s *SyncRBush) SearchItems(b BBox) []Interface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.SearchItems(b)
+}
+
+// SearchFunc holds the read lock while calling fn, so fn must not modify the index
+func (s *SyncRBush) SearchFunc(b BBox, fn func(Interface) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.rbush.SearchFunc(b, fn)
+}
+
+func (s *SyncRBush) Collides(b BBox) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.Collides(b)
+}
+
+func (s *SyncRBush) CollidesAll(boxes []BBox) []bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.CollidesAll(boxes)
+}
+
+func (s *SyncRBush) Knn(x, y float64, k int, maxDistance float64, filter func(item Interface) bool) []Interface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.Knn(x, y, k, maxDistance, filter)
+}
+
+func (s *SyncRBush) SearchWithin(x, y, distance float64) []Interface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.SearchWithin(x, y, distance)
+}
+
+func (s *SyncRBush) SearchWithinSq(x, y, sqDistance float64) []Interface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.SearchWithinSq(x, y, sqDistance)
+}
+
+func (s *SyncRBush) SearchWithinBBox(b BBox, distance float64) []Interface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.SearchWithinBBox(b, distance)
+}
+
+func (s *SyncRBush) SearchWithinBBoxSq(b BBox, sqDistance float64) []Interface {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.SearchWithinBBoxSq(b, sqDistance)
+}
+
+func (s *SyncRBush) GeoKnn(lon, lat float64, k int, maxDistance float64, filter func(item Interface) bool) []GeoNeighbour {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.GeoKnn(lon, lat, k, maxDistance, filter)
+}
+
+func (s *SyncRBush) GeoWithin(lon, lat, radius float64) []GeoNeighbour {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.GeoWithin(lon, lat, radius)
+}
+
+// Join holds the read lock of both indexes while calling fn, so fn must not modify them
+func (s *SyncRBush) Join(other *SyncRBush, fn func(a, b Interface) bool) {
+	defer s.rLockWith(other)()
+	s.rbush.Join(other.rbush, fn)
+}
+
+func (s *SyncRBush) JoinParallel(other *SyncRBush, workers int, fn func(a, b Interface) bool) {
+	defer s.rLockWith(other)()
+	s.rbush.JoinParallel(other.rbush, workers, fn)
+}
+
+func (s *SyncRBush) SelfJoin(fn func(a, b Interface) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.rbush.SelfJoin(fn)
+}
+
+func (s *SyncRBush) SelfJoinParallel(workers int, fn func(a, b Interface) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.rbush.SelfJoinParallel(workers, fn)
+}
+
+// read locks both indexes and returns the function that unlocks them. Locks are taken in order of address,
+// otherwise joins in opposite directions could deadlock with writers waiting on each index.
+// An index is only locked once, read locking it again could deadlock with a waiting writer
+func (s *SyncRBush) rLockWith(other *SyncRBush) func() {
+	if s == other {
+		s.mu.RLock()
+		return s.mu.RUnlock
+	}
+	first, second := s, other
+	if uintptr(unsafe.Pointer(second)) < uintptr(unsafe.Pointer(first)) {
+		first, second = second, first
+	}
+	first.mu.RLock()
+	second.mu.RLock()
+	return func() {
+		second.mu.RUnlock()
+		first.mu.RUnlock()
+	}
+}
+
+func (s *SyncRBush) All() []*Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.All()
+}
+
+func (s *SyncRBush) ToBBox() BBox {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.ToBBox()
+}
+
+func (s *SyncRBush) Check() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.Check()
+}
+
+func (s *SyncRBush) ToJSON(codec ItemCodec) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.ToJSON(codec)
+}
+
+func (s *SyncRBush) WriteBinary(w io.Writer, indexOf func(item Interface) int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rbush.WriteBinary(w, indexOf)
+}
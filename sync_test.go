@@ -0,0 +1,138 @@
+package go_rbush
+
+import (
+	"sync"
+	"testing"
+)
+
+// Meant to be run with -race
+func TestSyncRBush_ConcurrentAccess(t *testing.T) {
+	data := getData(2000, 1)
+	tree := NewSyncWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data[0:1000]...))
+	queries := getData(100, 10)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				q := queries[i%len(queries)]
+				b := BBox{q[0], q[1], q[2], q[3]}
+				tree.Search(b)
+				tree.SearchItems(b)
+				tree.Collides(b)
+				tree.Knn(q[0], q[1], 5, 0, nil)
+				tree.SearchWithin(q[0], q[1], 5)
+				tree.SearchWithinBBoxSq(b, 4)
+				tree.GeoKnn(q[0], q[1], 5, 0, nil)
+				tree.GeoWithin(q[0], q[1], 100)
+				tree.ToBBox()
+			}
+		}()
+	}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 1000; i < len(data); i++ {
+			assertNoError(t, tree.InsertElement(data[i:i+1]))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			removed, err := tree.RemoveElement(bboxToRemove(data[i]))
+			assertNoError(t, err)
+			assertEqual(t, removed, true, "")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 500; i < 600; i++ {
+			tree.Remove(bboxToRemove(data[i]))
+		}
+	}()
+	wg.Wait()
+
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), 1400, "")
+}
+
+// Meant to be run with -race. Snapshots are read without locking while the index keeps changing
+func TestSyncRBush_SnapshotReadsWithoutLocking(t *testing.T) {
+	data := getData(2000, 1)
+	tree := NewSyncWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data[0:1000]...))
+	snapshot := tree.Snapshot()
+	queries := getData(100, 10)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1000; i < len(data); i++ {
+			assertNoError(t, tree.InsertElement(data[i:i+1]))
+			tree.RemoveElement(bboxToRemove(data[i-1000]))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			for j := range queries {
+				x1, y1, x2, y2 := queries.GetBBoxAt(j)
+				snapshot.Search(BBox{x1, y1, x2, y2})
+			}
+		}
+	}()
+	wg.Wait()
+	assertNoError(t, snapshot.Check())
+	assertEqual(t, len(snapshot.All()), 1000, "")
+	assertEqual(t, len(tree.All()), 1000, "")
+}
+
+// Meant to be run with -race. Joins in opposite directions while both indexes are modified should not deadlock
+func TestSyncRBush_ConcurrentJoins(t *testing.T) {
+	data := getData(1000, 5)
+	a := NewSyncWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data[0:500]...))
+	b := NewSyncWithOptions(Options{MAX_ENTRIES: 4}).Load(append(bboxes{}, data[500:]...))
+	visit := func(x, y Interface) bool {
+		return true
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			a.Join(b, visit)
+			a.SelfJoin(visit)
+			a.Join(a, visit)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			b.JoinParallel(a, 2, visit)
+			b.SelfJoinParallel(2, visit)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			assertNoError(t, a.InsertElement(data[500+i:501+i]))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			assertNoError(t, b.InsertElement(data[i:i+1]))
+		}
+	}()
+	wg.Wait()
+
+	pairs := 0
+	a.Join(b, func(x, y Interface) bool {
+		pairs++
+		return true
+	})
+	assertEqual(t, pairs, len(bruteForceJoin(data, data)), "")
+}
@@ -0,0 +1,130 @@
+package go_rbush
+
+import "sync"
+
+// Traversals are depth first using an explicit stack, so only one branch of the tree is kept at a time.
+// Stacks and result buffers are reused between queries through pools
+
+type walkEntry[C Coordinate, E any] struct {
+	node      *rnode[C, E]
+	contained bool // the node is inside the query bbox, so all its items match
+}
+
+type walkStack[C Coordinate, E any] struct {
+	entries []walkEntry[C, E]
+}
+
+// pools are per type of tree, they are looked up by the type of the stack
+var walkStackPools sync.Map
+
+func getWalkStack[C Coordinate, E any]() *walkStack[C, E] {
+	pool, ok := walkStackPools.Load((*walkStack[C, E])(nil))
+	if !ok {
+		pool, _ = walkStackPools.LoadOrStore((*walkStack[C, E])(nil), &sync.Pool{
+			New: func() interface{} {
+				return &walkStack[C, E]{entries: make([]walkEntry[C, E], 0, 64)}
+			},
+		})
+	}
+	return pool.(*sync.Pool).Get().(*walkStack[C, E])
+}
+
+// Stale entries are not cleared, the pool itself is emptied by the garbage collector
+func putWalkStack[C Coordinate, E any](stack *walkStack[C, E]) {
+	stack.entries = stack.entries[:0]
+	pool, _ := walkStackPools.Load((*walkStack[C, E])(nil))
+	pool.(*sync.Pool).Put(stack)
+}
+
+// buffers are not returned to the pool above this capacity so huge results do not stay in memory
+const maxPooledItems = 1 << 16
+
+// position of an item in a leaf. The leaf is an *rnode of any type so that all the trees share the buffers
+type itemRef struct {
+	leaf  interface{}
+	index int
+}
+
+type itemBuffer struct {
+	refs []itemRef
+}
+
+var itemBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &itemBuffer{refs: make([]itemRef, 0, 64)}
+	},
+}
+
+func getItemBuffer() *itemBuffer {
+	return itemBufferPool.Get().(*itemBuffer)
+}
+
+func putItemBuffer(buf *itemBuffer) {
+	if cap(buf.refs) > maxPooledItems {
+		return
+	}
+	// do not keep references to the nodes
+	clear(buf.refs)
+	buf.refs = buf.refs[:0]
+	itemBufferPool.Put(buf)
+}
+
+// visit, in order, the items below n that intersect the bbox, all of them if n is contained in it.
+// Returns false if fn stopped the walk
+func (n *rnode[C, E]) walk(b []C, contained bool, fn func(leaf *rnode[C, E], i int) bool) bool {
+	stack := getWalkStack[C, E]()
+	defer putWalkStack(stack)
+	stack.entries = append(stack.entries, walkEntry[C, E]{n, contained})
+	for len(stack.entries) != 0 {
+		e := stack.entries[len(stack.entries)-1]
+		stack.entries = stack.entries[:len(stack.entries)-1]
+		node := e.node
+		if node.isLeaf {
+			for i := range node.values {
+				if (e.contained || boxIntersects(b, node.entryBox(i))) && !fn(node, i) {
+					return false
+				}
+			}
+			continue
+		}
+		// pushed in reverse so children are visited in order
+		for i := len(node.children) - 1; i >= 0; i-- {
+			c := node.children[i]
+			if e.contained {
+				stack.entries = append(stack.entries, walkEntry[C, E]{c, true})
+			} else if boxIntersects(b, c.bbox) {
+				stack.entries = append(stack.entries, walkEntry[C, E]{c, boxContains(b, c.bbox)})
+			}
+		}
+	}
+	return true
+}
+
+// tells whether some item below n intersects the bbox, stopping at the first one
+func (n *rnode[C, E]) collides(b []C) bool {
+	stack := getWalkStack[C, E]()
+	defer putWalkStack(stack)
+	stack.entries = append(stack.entries, walkEntry[C, E]{node: n})
+	for len(stack.entries) != 0 {
+		node := stack.entries[len(stack.entries)-1].node
+		stack.entries = stack.entries[:len(stack.entries)-1]
+		if node.isLeaf {
+			for i := range node.values {
+				if boxIntersects(b, node.entryBox(i)) {
+					return true
+				}
+			}
+			continue
+		}
+		for _, c := range node.children {
+			if boxIntersects(b, c.bbox) {
+				// nodes are never empty
+				if boxContains(b, c.bbox) {
+					return true
+				}
+				stack.entries = append(stack.entries, walkEntry[C, E]{node: c})
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package go_rbush
+
+// Move describes an item whose bbox changed. Old identifies the stored item as in Remove and New replaces it
+type Move struct {
+	Old ToBeRemoved
+	New Interface
+}
+
+// Update replaces the item identified by old with p, which is expected to have length 1.
+// If the bbox of p still fits in the leaf holding old the item is replaced in place,
+// otherwise it is removed and p is inserted again. Reports whether old was found, p is not inserted otherwise
+func (r *RBush) Update(old ToBeRemoved, p Interface) (bool, error) {
+	b, match := toBeRemovedItem(old)
+	moved := interfaceBBox(p).flat()
+	return r.update(b[:], match, moved[:], item{points: p})
+}
+
+// UpdateAll applies a batch of moves. Items that still fit in their leaf are updated in place,
+// the rest are removed first and then inserted again. Returns the number of items that were found
+func (r *RBush) UpdateAll(moves []Move) (int, error) {
+	if err := r.checkWritable(); err != nil {
+		return 0, err
+	}
+	updated := 0
+	relocated := make([]Interface, 0)
+	for _, m := range moves {
+		b, match := toBeRemovedItem(m.Old)
+		indexes := r.findItem(b[:], match)
+		if indexes == nil {
+			continue
+		}
+		updated++
+		moved := interfaceBBox(m.New).flat()
+		needsInsert, err := r.replaceOrRemove(indexes, moved[:], item{points: m.New})
+		if err != nil {
+			return updated, err
+		}
+		if needsInsert {
+			relocated = append(relocated, m.New)
+		}
+	}
+	for _, p := range relocated {
+		if err := r.InsertElement(p); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// replace the item with the bbox for which match returns true, see RBush.Update
+func (t *rtree[C, E]) update(box []C, match func(E) bool, moved []C, value E) (bool, error) {
+	if err := t.checkWritable(); err != nil {
+		return false, err
+	}
+	indexes := t.findItem(box, match)
+	if indexes == nil {
+		return false, nil
+	}
+	needsInsert, err := t.replaceOrRemove(indexes, moved, value)
+	if err != nil || !needsInsert {
+		return true, err
+	}
+	return true, t.insert(moved, value)
+}
+
+// Replace the item found at indexes if the new bbox fits in the leaf, otherwise remove it. Reports whether it still needs to be inserted
+func (t *rtree[C, E]) replaceOrRemove(indexes []int, box []C, value E) (bool, error) {
+	path := t.mutablePath(indexes[:len(indexes)-1])
+	leaf := path[len(path)-1]
+	index := indexes[len(indexes)-1]
+	if boxContains(leaf.bbox, box) {
+		copy(leaf.entryBox(index), box)
+		leaf.values[index] = value
+		updateBBoxUpwards(path)
+		return false, nil
+	}
+	leaf.removeItem(index)
+	return true, t.condense(path)
+}
+
+// Recompute bboxes from the end of the path to the root. Bboxes can only shrink, so we stop as soon as one does not change
+func updateBBoxUpwards[C Coordinate, E any](path []*rnode[C, E]) {
+	previous := make([]C, len(path[0].bbox))
+	for level := len(path) - 1; level >= 0; level-- {
+		node := path[level]
+		copy(previous, node.bbox)
+		node.updateBBox()
+		if boxEquals(previous, node.bbox) {
+			return
+		}
+	}
+}
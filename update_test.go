@@ -0,0 +1,88 @@
+package go_rbush
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRBush_UpdateInPlace(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	item := data[10]
+	leaf := findLeaf(tree, bboxToRemove(item))
+	// move the item to a corner of its leaf
+	moved := bboxes{{leaf.bbox[0], leaf.bbox[1], leaf.bbox[0], leaf.bbox[1]}}
+	found, err := tree.Update(bboxToRemove(item), moved)
+	assertNoError(t, err)
+	assertEqual(t, found, true, "")
+	newLeaf := findLeaf(tree, bboxToRemove(moved[0]))
+	assertEqual(t, newLeaf, leaf, "Item should stay in the same leaf")
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), len(data), "")
+}
+
+func TestRBush_UpdateRelocates(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	item := data[0]
+	moved := bboxes{{150, 150, 160, 160}}
+	found, err := tree.Update(bboxToRemove(item), moved)
+	assertNoError(t, err)
+	assertEqual(t, found, true, "")
+	assertNoError(t, tree.Check())
+	assertEqual(t, len(tree.All()), len(data), "")
+	assertEqual(t, tree.ToBBox(), BBox{0, 0, 160, 160}, "")
+	assertEqual(t, len(tree.Search(BBox{149, 149, 151, 151})), 1, "")
+	found, _ = tree.Update(bboxToRemove(item), moved)
+	assertEqual(t, found, false, "Old item should not be in the tree")
+}
+
+func TestRBush_UpdateNotFound(t *testing.T) {
+	data := getDataExample()
+	tree := NewWithOptions(Options{MAX_ENTRIES: 4}).Load(data)
+	found, err := tree.Update(bboxToRemove{200, 200, 200, 200}, bboxes{{1, 1, 1, 1}})
+	assertNoError(t, err)
+	assertEqual(t, found, false, "")
+	assertEqual(t, len(tree.All()), len(data), "")
+}
+
+func TestRBush_UpdateAll(t *testing.T) {
+	data := getData(1000, 1)
+	tree := NewWithOptions(Options{MAX_ENTRIES: 9}).Load(append(bboxes{}, data...))
+	moves := make([]Move, 0, len(data)/2)
+	positions := append(bboxes{}, data...)
+	for i := 0; i < len(data); i += 2 {
+		// half of them move slightly, the rest jump anywhere
+		moved := randBox(1)
+		if i%4 == 0 {
+			d := rand.Float64() * 0.01
+			moved = [4]float64{data[i][0] + d, data[i][1] + d, data[i][2], data[i][3]}
+		}
+		moves = append(moves, Move{Old: bboxToRemove(data[i]), New: bboxes{moved}})
+		positions[i] = moved
+	}
+	moves = append(moves, Move{Old: bboxToRemove{200, 200, 200, 200}, New: bboxes{{1, 1, 1, 1}}})
+	updated, err := tree.UpdateAll(moves)
+	assertNoError(t, err)
+	assertEqual(t, updated, len(data)/2, "")
+	assertNoError(t, tree.Check())
+	for _, p := range positions {
+		leaf := findLeaf(tree, bboxToRemove(p))
+		assertEqual(t, leaf != nil, true, "")
+	}
+	assertEqual(t, len(tree.All()), len(data), "")
+}
+
+// leaf holding the item, nil if it is not in the tree
+func findLeaf(tree *RBush, p ToBeRemoved) *rbushNode {
+	b, match := toBeRemovedItem(p)
+	indexes := tree.findItem(b[:], match)
+	if indexes == nil {
+		return nil
+	}
+	n := tree.rootNode
+	for _, i := range indexes[:len(indexes)-1] {
+		n = n.children[i]
+	}
+	return n
+}